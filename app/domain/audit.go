@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditActionOrderCreate        AuditAction = "order.create"
+	AuditActionShippingUpdate     AuditAction = "order.shipping_update"
+	AuditActionOrderUpdate        AuditAction = "order.update"
+	AuditActionExpiryExtend       AuditAction = "order.expiry_extend"
+	AuditActionPaymentCallback    AuditAction = "order.payment_callback"
+	AuditActionPaymentEvent       AuditAction = "order.payment_event"
+	AuditActionLatePayment        AuditAction = "order.late_payment"
+	AuditActionRefundRequest      AuditAction = "order.refund_request"
+	AuditActionRefundConfirm      AuditAction = "order.refund_confirm"
+	AuditActionStockReleased      AuditAction = "order.stock_released"
+	AuditActionOrderExpire        AuditAction = "order.expire"
+	AuditActionOrderAccept        AuditAction = "order.accept"
+	AuditActionOrderReject        AuditAction = "order.reject"
+	AuditActionOrderShip          AuditAction = "order.ship"
+	AuditActionOrderForceCancel   AuditAction = "order.force_cancel"
+	AuditActionOrderForceComplete AuditAction = "order.force_complete"
+	AuditActionWebhookCreate      AuditAction = "webhook.create"
+	AuditActionWebhookUpdate      AuditAction = "webhook.update"
+	AuditActionWebhookDelete      AuditAction = "webhook.delete"
+	AuditActionWebhookReplay      AuditAction = "webhook.replay"
+	AuditActionCouponCreate       AuditAction = "coupon.create"
+	AuditActionCouponUpdate       AuditAction = "coupon.update"
+)
+
+// AuditResourceType is the kind of record an audit log is about.
+type AuditResourceType string
+
+const (
+	AuditResourceOrder               AuditResourceType = "order"
+	AuditResourceWebhookSubscription AuditResourceType = "webhook_subscription"
+	AuditResourceWebhookDelivery     AuditResourceType = "webhook_delivery"
+	AuditResourceCoupon              AuditResourceType = "coupon"
+)
+
+// Actor identifies who performed a state changing operation. ID is zero for
+// the system and for callers authenticated by a shared secret.
+type Actor struct {
+	Type ActorType
+	ID   int64
+}
+
+// AuditLog is an append-only record of a state changing operation. Before is
+// null for creations and After is null for deletions. OrderID is only set
+// for changes to an order.
+type AuditLog struct {
+	ID           int64             `json:"id"`
+	ActorType    ActorType         `json:"actor_type"`
+	ActorID      int64             `json:"actor_id"`
+	Action       AuditAction       `json:"action"`
+	ResourceType AuditResourceType `json:"resource_type"`
+	ResourceID   int64             `json:"resource_id"`
+	OrderID      *int64            `json:"order_id"`
+	Before       json.RawMessage   `json:"before"`
+	After        json.RawMessage   `json:"after"`
+	RequestID    string            `json:"request_id"`
+	SourceIP     string            `json:"source_ip"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+type AuditFilter struct {
+	ActorType    string `query:"actor_type" validate:"omitempty,oneof=user seller payment system admin"`
+	ActorID      int64  `query:"actor_id" validate:"omitempty,id"`
+	Action       string `query:"action" validate:"omitempty,max=50"`
+	ResourceType string `query:"resource_type" validate:"omitempty,oneof=order webhook_subscription webhook_delivery coupon"`
+	ResourceID   int64  `query:"resource_id" validate:"omitempty,id"`
+	OrderID      int64  `query:"order_id" validate:"omitempty,id"`
+	RequestID    string `query:"request_id" validate:"omitempty,max=64"`
+	CreatedFrom  string `query:"created_from" validate:"omitempty,datetime=2006-01-02"`
+	CreatedTo    string `query:"created_to" validate:"omitempty,datetime=2006-01-02"`
+	Page         int    `query:"page" validate:"omitempty,gte=1"`
+	Limit        int    `query:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+// Normalize fills in the paging defaults.
+func (f *AuditFilter) Normalize() {
+	if f.Page == 0 {
+		f.Page = 1
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultPageLimit
+	}
+}
+
+type AuditRepository interface {
+	// CreateAuditLog must be called with the transaction of the change it records.
+	CreateAuditLog(ctx context.Context, log *AuditLog, tx *sql.Tx) error
+	SearchAuditLogs(ctx context.Context, filter AuditFilter) ([]AuditLog, error)
+}
+
+type AuditUsecase interface {
+	SearchAuditLogs(ctx context.Context, filter AuditFilter) ([]AuditLog, error)
+}
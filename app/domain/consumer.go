@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Delivery is a message received from the broker. Attempt starts at 1 and is
+// increased on every redelivery.
+type Delivery struct {
+	Message
+	Attempt int
+}
+
+// MessageHandler processes a delivery. Returning nil acknowledges it, an
+// error asks the broker to deliver it again later.
+type MessageHandler func(ctx context.Context, delivery Delivery) error
+
+type EventSubscriber interface {
+	// Subscribe delivers the messages of topic to handler, one at a time,
+	// and blocks until ctx is done.
+	Subscribe(ctx context.Context, topic string, handler MessageHandler) error
+	Close() error
+}
+
+// PaymentEventPayload is the payload of the payment-succeeded and
+// payment-failed topics.
+type PaymentEventPayload struct {
+	OrderID   int64  `json:"order_id" validate:"required,id"`
+	PaymentID string `json:"payment_id" validate:"max=100"`
+	Reason    string `json:"reason" validate:"max=255"`
+}
+
+// StockReleasedPayload is the payload of the stock-released topic, sent when
+// the warehouse gives up the reservation of an order on its own.
+type StockReleasedPayload struct {
+	OrderID int64  `json:"order_id" validate:"required,id"`
+	Reason  string `json:"reason" validate:"max=255"`
+}
+
+// DeadLetter is a received message that could not be processed, kept for
+// manual inspection.
+type DeadLetter struct {
+	ID        int64     `json:"id"`
+	MessageID string    `json:"message_id"`
+	Topic     string    `json:"topic"`
+	Payload   []byte    `json:"payload"`
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type InboxRepository interface {
+	// MarkMessageProcessed records msg as processed within tx and reports
+	// false when it was processed before.
+	MarkMessageProcessed(ctx context.Context, msg Message, tx *sql.Tx) (bool, error)
+	CreateDeadLetter(ctx context.Context, deadLetter *DeadLetter) error
+}
+
+type InboxUsecase interface {
+	// DeadLetter stores a delivery that will not be retried anymore.
+	DeadLetter(ctx context.Context, delivery Delivery, cause error) error
+}
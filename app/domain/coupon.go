@@ -0,0 +1,175 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+type CouponType string
+
+const (
+	CouponTypePercentage   CouponType = "percentage"
+	CouponTypeFixedAmount  CouponType = "fixed_amount"
+	CouponTypeFreeShipping CouponType = "free_shipping"
+)
+
+// Coupon is a promo code applied when an order is created. Amounts are in the
+// minor unit of the currency.
+type Coupon struct {
+	ID   int64      `json:"id"`
+	Code string     `json:"code"`
+	Type CouponType `json:"type"`
+	// Value is the percentage off for percentage coupons and the amount off
+	// for fixed_amount coupons, free_shipping coupons do not use it.
+	Value int64 `json:"value"`
+	// MaxDiscount caps a percentage discount, zero means no cap.
+	MaxDiscount int64      `json:"max_discount"`
+	MinSubtotal int64      `json:"min_subtotal"`
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+	// UsageLimit and PerUserLimit count redemptions that were not released,
+	// zero means unlimited.
+	UsageLimit   int64 `json:"usage_limit"`
+	PerUserLimit int64 `json:"per_user_limit"`
+	// ProductIDs lists the eligible products, every product is eligible when
+	// it is empty.
+	ProductIDs []int64 `json:"product_ids"`
+	Active     bool    `json:"active"`
+	// Redemptions is the number of redemptions that were not released.
+	Redemptions int64     `json:"redemptions"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ValidAt reports whether the coupon can be redeemed at t.
+func (c Coupon) ValidAt(t time.Time) bool {
+	if !c.Active {
+		return false
+	}
+	if c.StartsAt != nil && t.Before(*c.StartsAt) {
+		return false
+	}
+	return c.EndsAt == nil || t.Before(*c.EndsAt)
+}
+
+// AppliesTo reports whether productID is eligible for the coupon.
+func (c Coupon) AppliesTo(productID int64) bool {
+	if len(c.ProductIDs) == 0 {
+		return true
+	}
+	for _, id := range c.ProductIDs {
+		if id == productID {
+			return true
+		}
+	}
+	return false
+}
+
+// Discount returns what the coupon takes off the items and off the shipping
+// fee. Neither is ever more than what it is taken off.
+func (c Coupon) Discount(subtotal, shippingFee int64) (item, shipping int64) {
+	switch c.Type {
+	case CouponTypePercentage:
+		item = subtotal * c.Value / 100
+		if c.MaxDiscount > 0 {
+			item = min(item, c.MaxDiscount)
+		}
+	case CouponTypeFixedAmount:
+		item = min(c.Value, subtotal)
+	case CouponTypeFreeShipping:
+		shipping = shippingFee
+	}
+	return item, shipping
+}
+
+// CouponRequest creates or replaces a coupon. Codes are case insensitive and
+// stored upper cased.
+type CouponRequest struct {
+	Code         string     `json:"code" validate:"required,coupon_code"`
+	Type         string     `json:"type" validate:"required,oneof=percentage fixed_amount free_shipping"`
+	Value        int64      `json:"value" validate:"required_unless=Type free_shipping,gte=0"`
+	MaxDiscount  int64      `json:"max_discount" validate:"gte=0"`
+	MinSubtotal  int64      `json:"min_subtotal" validate:"gte=0"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	UsageLimit   int64      `json:"usage_limit" validate:"gte=0"`
+	PerUserLimit int64      `json:"per_user_limit" validate:"gte=0"`
+	ProductIDs   []int64    `json:"product_ids" validate:"max=100,dive,id"`
+	Active       *bool      `json:"active"`
+}
+
+// NormalizeCouponCode makes codes case insensitive.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+type CouponFilter struct {
+	Page  int `query:"page" validate:"omitempty,gte=1"`
+	Limit int `query:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+// Normalize fills in the paging defaults.
+func (f *CouponFilter) Normalize() {
+	if f.Page == 0 {
+		f.Page = 1
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultPageLimit
+	}
+}
+
+type CouponRedemptionStatus string
+
+const (
+	CouponRedemptionRedeemed CouponRedemptionStatus = "redeemed"
+	// CouponRedemptionReleased is set when the order is cancelled or expires,
+	// the redemption no longer counts against the usage limits.
+	CouponRedemptionReleased CouponRedemptionStatus = "released"
+)
+
+// CouponRedemption is a coupon applied to an order. Code and Type are copied
+// from the coupon, so the order keeps showing what was applied.
+type CouponRedemption struct {
+	ID               int64                  `json:"-"`
+	CouponID         int64                  `json:"coupon_id"`
+	OrderID          int64                  `json:"-"`
+	UserID           int64                  `json:"-"`
+	Code             string                 `json:"code"`
+	Type             CouponType             `json:"type"`
+	ItemDiscount     int64                  `json:"item_discount"`
+	ShippingDiscount int64                  `json:"shipping_discount"`
+	Status           CouponRedemptionStatus `json:"status"`
+	CreatedAt        time.Time              `json:"-"`
+	ReleasedAt       *time.Time             `json:"released_at,omitempty"`
+}
+
+type CouponRepository interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error
+	CreateCoupon(ctx context.Context, coupon *Coupon, tx *sql.Tx) error
+	GetCouponByID(ctx context.Context, id int64) (Coupon, error)
+	GetCouponByIDForUpdate(ctx context.Context, id int64, tx *sql.Tx) (Coupon, error)
+	ListCoupons(ctx context.Context, filter CouponFilter) ([]Coupon, error)
+	UpdateCoupon(ctx context.Context, coupon *Coupon, tx *sql.Tx) error
+	// GetCouponByCodeForUpdate locks the coupon until tx ends, so concurrent
+	// redemptions of it are checked against the limits one after another.
+	GetCouponByCodeForUpdate(ctx context.Context, code string, tx *sql.Tx) (Coupon, error)
+	// CountUserRedemptions counts the redemptions of a user that were not released.
+	CountUserRedemptions(ctx context.Context, couponID, userID int64, tx *sql.Tx) (int64, error)
+	CreateRedemption(ctx context.Context, redemption *CouponRedemption, tx *sql.Tx) error
+	// UpdateRedemptionDiscount replaces the discounts of the redemption of an
+	// order after its quantity changed.
+	UpdateRedemptionDiscount(ctx context.Context, orderID, itemDiscount, shippingDiscount int64, tx *sql.Tx) error
+	// ReleaseRedemption releases the coupon of an order, if it has one.
+	ReleaseRedemption(ctx context.Context, orderID int64, tx *sql.Tx) error
+	// RestoreRedemption redeems the released coupon of an order again.
+	RestoreRedemption(ctx context.Context, orderID int64, tx *sql.Tx) error
+}
+
+type CouponUsecase interface {
+	CreateCoupon(ctx context.Context, adminID int64, req CouponRequest) (Coupon, error)
+	GetCouponByID(ctx context.Context, id int64) (Coupon, error)
+	ListCoupons(ctx context.Context, filter CouponFilter) ([]Coupon, error)
+	UpdateCoupon(ctx context.Context, adminID, id int64, req CouponRequest) (Coupon, error)
+}
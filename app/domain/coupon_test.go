@@ -0,0 +1,31 @@
+package domain
+
+import "testing"
+
+func TestCouponDiscount(t *testing.T) {
+	tests := []struct {
+		name         string
+		coupon       Coupon
+		subtotal     int64
+		shippingFee  int64
+		wantItem     int64
+		wantShipping int64
+	}{
+		{name: "percentage", coupon: Coupon{Type: CouponTypePercentage, Value: 10}, subtotal: 50000, shippingFee: 10000, wantItem: 5000},
+		{name: "percentage rounds down", coupon: Coupon{Type: CouponTypePercentage, Value: 15}, subtotal: 999, wantItem: 149},
+		{name: "percentage capped", coupon: Coupon{Type: CouponTypePercentage, Value: 50, MaxDiscount: 20000}, subtotal: 100000, wantItem: 20000},
+		{name: "percentage under the cap", coupon: Coupon{Type: CouponTypePercentage, Value: 50, MaxDiscount: 20000}, subtotal: 30000, wantItem: 15000},
+		{name: "fixed amount", coupon: Coupon{Type: CouponTypeFixedAmount, Value: 15000}, subtotal: 50000, shippingFee: 10000, wantItem: 15000},
+		{name: "fixed amount above the subtotal", coupon: Coupon{Type: CouponTypeFixedAmount, Value: 15000}, subtotal: 9000, shippingFee: 10000, wantItem: 9000},
+		{name: "free shipping", coupon: Coupon{Type: CouponTypeFreeShipping}, subtotal: 50000, shippingFee: 25000, wantShipping: 25000},
+		{name: "free shipping without a fee", coupon: Coupon{Type: CouponTypeFreeShipping}, subtotal: 50000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item, shipping := tt.coupon.Discount(tt.subtotal, tt.shippingFee)
+			if item != tt.wantItem || shipping != tt.wantShipping {
+				t.Errorf("Discount() = %d, %d, want %d, %d", item, shipping, tt.wantItem, tt.wantShipping)
+			}
+		})
+	}
+}
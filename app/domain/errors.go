@@ -2,11 +2,95 @@ package domain
 
 import "errors"
 
+// CodeError is a business rule violation with a stable code that clients can
+// map to a message. Err is the sentinel deciding the HTTP status.
+type CodeError struct {
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *CodeError) Error() string {
+	return e.Message
+}
+
+func (e *CodeError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrBadRequest         = errors.New("bad request")
+	ErrInvalidRequest     = errors.New("invalid request")
+	ErrValidation         = errors.New("validation error")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
+	ErrConflict           = errors.New("conflict")
+	ErrTooManyRequest     = errors.New("too many requests")
+	ErrPreconditionFailed = errors.New("precondition failed")
+	ErrInternal           = errors.New("internal server error")
+)
+
 var (
-	ErrNotFound       = errors.New("not found")
-	ErrBadRequest     = errors.New("bad request")
-	ErrInvalidRequest = errors.New("invalid request")
-	ErrValidation     = errors.New("validation error")
-	ErrUnauthorized   = errors.New("unauthorized")
-	ErrInternal       = errors.New("internal server error")
+	ErrUnpaidOrderLimit = &CodeError{
+		Code:    "UNPAID_ORDER_LIMIT",
+		Message: "unpaid order limit reached",
+		Err:     ErrConflict,
+	}
+	ErrReservedQuantityLimit = &CodeError{
+		Code:    "RESERVED_QUANTITY_LIMIT",
+		Message: "reserved quantity limit for this product reached",
+		Err:     ErrConflict,
+	}
+	ErrCouponInvalid = &CodeError{
+		Code:    "COUPON_INVALID",
+		Message: "coupon does not exist or is not valid now",
+		Err:     ErrBadRequest,
+	}
+	ErrCouponNotEligible = &CodeError{
+		Code:    "COUPON_NOT_ELIGIBLE",
+		Message: "coupon does not apply to this order",
+		Err:     ErrBadRequest,
+	}
+	ErrCouponUsageLimit = &CodeError{
+		Code:    "COUPON_USAGE_LIMIT",
+		Message: "coupon usage limit reached",
+		Err:     ErrConflict,
+	}
+	ErrQuoteInvalid = &CodeError{
+		Code:    "QUOTE_INVALID",
+		Message: "quote token is invalid or was made for another order",
+		Err:     ErrBadRequest,
+	}
+	// ErrQuoteExpired means the quoted prices are no longer held, the client
+	// should ask for a new quote.
+	ErrQuoteExpired = &CodeError{
+		Code:    "QUOTE_EXPIRED",
+		Message: "quote token has expired",
+		Err:     ErrBadRequest,
+	}
+	ErrExpiryExtended = &CodeError{
+		Code:    "EXPIRY_ALREADY_EXTENDED",
+		Message: "payment window of the order was already extended",
+		Err:     ErrConflict,
+	}
+	// ErrLastOrderLine refuses an edit that would leave an order without lines,
+	// an unpaid order is dropped by letting its payment window end.
+	ErrLastOrderLine = &CodeError{
+		Code:    "LAST_ORDER_LINE",
+		Message: "the last line of an order cannot be removed",
+		Err:     ErrConflict,
+	}
+	ErrHighDemandProduct = &CodeError{
+		Code:    "HIGH_DEMAND_PRODUCT",
+		Message: "payment window of high demand products cannot be extended",
+		Err:     ErrConflict,
+	}
+	// ErrVersionConflict means the order changed between reading and writing
+	// it, the caller should read it again.
+	ErrVersionConflict = &CodeError{
+		Code:    "VERSION_CONFLICT",
+		Message: "order was modified concurrently",
+		Err:     ErrConflict,
+	}
 )
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type OrderEventType string
+
+const (
+	OrderEventCreated   OrderEventType = "order.created"
+	OrderEventPaid      OrderEventType = "order.paid"
+	OrderEventCancelled OrderEventType = "order.cancelled"
+	OrderEventExpired   OrderEventType = "order.expired"
+	// OrderEventRefundRequired tells the payment team that a payment was
+	// taken for an order that cannot be fulfilled.
+	OrderEventRefundRequired OrderEventType = "order.refund_required"
+	// OrderEventRefundRequested asks the payment provider to pay out the
+	// refund in the event, OrderEventRefunded tells that it was paid out.
+	OrderEventRefundRequested OrderEventType = "order.refund_requested"
+	OrderEventRefunded        OrderEventType = "order.refunded"
+)
+
+// OrderEventVersion is the schema version of OrderEvent. It is bumped on
+// incompatible payload changes and is part of the broker topic, so consumers
+// can migrate at their own pace.
+const OrderEventVersion = 1
+
+// OrderEvent is the payload sent to partners and to the message broker when
+// an order changes.
+type OrderEvent struct {
+	ID         string         `json:"id"`
+	Type       OrderEventType `json:"type"`
+	Version    int            `json:"version"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Data       OrderEventData `json:"data"`
+	// Refund is set on refund events only.
+	Refund *Refund `json:"refund,omitempty"`
+}
+
+// OrderEventData is the order in an OrderEvent. It is limited to IDs, status
+// and totals, the shipping address and the notes of the customer never leave
+// the service.
+type OrderEventData struct {
+	ID             int64          `json:"id"`
+	ProductID      int64          `json:"product_id"`
+	Quantity       int64          `json:"quantity"`
+	UserID         int64          `json:"user_id"`
+	ShopID         int64          `json:"shop_id"`
+	Status         OrderStatus    `json:"status"`
+	DeliveryMethod DeliveryMethod `json:"delivery_method"`
+	Subtotal       int64          `json:"subtotal"`
+	ShippingFee    int64          `json:"shipping_fee"`
+	Discount       int64          `json:"discount"`
+	Tax            int64          `json:"tax"`
+	Total          int64          `json:"total"`
+	// RefundedQuantity and RefundedAmount add up the succeeded refunds.
+	RefundedQuantity int64     `json:"refunded_quantity"`
+	RefundedAmount   int64     `json:"refunded_amount"`
+	ExpiredAt        time.Time `json:"expired_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+	Version          int64     `json:"version"`
+}
+
+// NewOrderEventData returns the event data of order.
+func NewOrderEventData(order Order) OrderEventData {
+	return OrderEventData{
+		ID:               order.ID,
+		ProductID:        order.ProductID,
+		Quantity:         order.Quantity,
+		UserID:           order.UserID,
+		ShopID:           order.ShopID,
+		Status:           order.Status,
+		DeliveryMethod:   order.DeliveryMethod,
+		Subtotal:         order.Pricing.Subtotal,
+		ShippingFee:      order.Pricing.ShippingFee,
+		Discount:         order.Pricing.Discount,
+		Tax:              order.Pricing.Tax,
+		Total:            order.Pricing.Total,
+		RefundedQuantity: order.RefundedQuantity,
+		RefundedAmount:   order.RefundedAmount,
+		ExpiredAt:        order.ExpiredAt,
+		UpdatedAt:        order.UpdatedAt,
+		Version:          order.Version,
+	}
+}
+
+// Message is a single broker message. ID is unique per message and lets the
+// broker and consumers drop duplicates.
+type Message struct {
+	ID      string
+	Topic   string
+	Key     string
+	Payload []byte
+}
+
+// EventPublisher publishes messages to a message broker. Publish returns only
+// once the broker has accepted the message.
+type EventPublisher interface {
+	Publish(ctx context.Context, msg Message) error
+	Close() error
+}
+
+// OutboxEvent is a message written in the transaction of the change that
+// produced it and published to the broker afterwards.
+type OutboxEvent struct {
+	ID            int64
+	Message       Message
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	PublishedAt   *time.Time
+	CreatedAt     time.Time
+}
+
+type OutboxRepository interface {
+	CreateOutboxEvent(ctx context.Context, event *OutboxEvent, tx *sql.Tx) error
+	// ClaimPendingOutboxEvents returns unpublished events that are due, oldest
+	// first, and pushes their next attempt back by lease.
+	ClaimPendingOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]OutboxEvent, error)
+	MarkOutboxEventPublished(ctx context.Context, id int64) error
+	MarkOutboxEventFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error
+}
+
+type EventRelayUsecase interface {
+	PublishPendingEvents(ctx context.Context)
+}
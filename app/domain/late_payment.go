@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// LatePayment records a successful payment that arrived for a cancelled
+// order. Outcome is the status the order was moved to: paid when the stock
+// could be reserved again, refund_required otherwise.
+type LatePayment struct {
+	ID        int64       `json:"id"`
+	OrderID   int64       `json:"order_id"`
+	PaymentID string      `json:"payment_id,omitempty"`
+	Outcome   OrderStatus `json:"outcome"`
+	Reason    string      `json:"reason,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type LatePaymentFilter struct {
+	Outcome string `query:"outcome" validate:"omitempty,oneof=paid refund_required"`
+	OrderID int64  `query:"order_id" validate:"omitempty,id"`
+	Page    int    `query:"page" validate:"omitempty,gte=1"`
+	Limit   int    `query:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+// Normalize fills in the paging defaults.
+func (f *LatePaymentFilter) Normalize() {
+	if f.Page == 0 {
+		f.Page = 1
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultPageLimit
+	}
+}
+
+type LatePaymentRepository interface {
+	CreateLatePayment(ctx context.Context, payment *LatePayment, tx *sql.Tx) error
+	ListLatePayments(ctx context.Context, filter LatePaymentFilter) ([]LatePayment, error)
+}
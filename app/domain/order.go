@@ -3,6 +3,7 @@ package domain
 import (
 	"context"
 	"database/sql"
+	"log/slog"
 	"time"
 )
 
@@ -12,34 +13,229 @@ const (
 	OrderStatusWaitingPayment OrderStatus = "waiting_payment"
 	OrderStatusPaid           OrderStatus = "paid"
 	OrderStatusCancelled      OrderStatus = "cancelled"
+	OrderStatusAccepted       OrderStatus = "accepted"
+	OrderStatusShipped        OrderStatus = "shipped"
+	OrderStatusRefundPending  OrderStatus = "refund_pending"
+	OrderStatusCompleted      OrderStatus = "completed"
+	// OrderStatusRefundRequired is set when a payment arrived for an order
+	// that can no longer be fulfilled, the payment team has to refund it.
+	OrderStatusRefundRequired OrderStatus = "refund_required"
+	// OrderStatusRefunded is set once every unit has been refunded. A partial
+	// refund keeps the status, so the rest of the order can still be
+	// fulfilled, and is tracked in the refunded quantity and amount.
+	OrderStatusRefunded OrderStatus = "refunded"
 )
 
+// orderTransitions lists the statuses an order may move to from each status.
+// Every status that may move to refunded can be refunded.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusWaitingPayment: {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:           {OrderStatusAccepted, OrderStatusRefundPending, OrderStatusRefunded},
+	OrderStatusAccepted:       {OrderStatusShipped, OrderStatusRefundPending, OrderStatusRefunded},
+	OrderStatusShipped:        {OrderStatusCompleted, OrderStatusRefunded},
+	OrderStatusCompleted:      {OrderStatusRefunded},
+	OrderStatusRefundPending:  {OrderStatusRefunded},
+	OrderStatusRefundRequired: {OrderStatusRefunded},
+}
+
+// IsFinal reports whether no further change, including admin overrides, is
+// allowed on an order in this status.
+func (s OrderStatus) IsFinal() bool {
+	return s == OrderStatusCancelled || s == OrderStatusCompleted || s == OrderStatusRefundPending ||
+		s == OrderStatusRefundRequired || s == OrderStatusRefunded
+}
+
+// CanBeRefunded reports whether money was taken for an order in this status
+// and not all of it has been returned.
+func (s OrderStatus) CanBeRefunded() bool {
+	return s.CanTransitionTo(OrderStatusRefunded)
+}
+
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 type Order struct {
 	ID        int64       `json:"id"`
 	ProductID int64       `json:"product_id"`
 	Quantity  int64       `json:"quantity"`
 	UserID    int64       `json:"user_id"`
+	ShopID    int64       `json:"shop_id"`
 	Status    OrderStatus `json:"status"`
+	Notes     string      `json:"notes,omitempty"`
 	CreatedAt time.Time   `json:"created_at"`
 	UpdatedAt time.Time   `json:"updated_at"`
 	ExpiredAt time.Time   `json:"expired_at"`
+	// ExpiryExtendedAt is set once the payment window was extended.
+	ExpiryExtendedAt *time.Time `json:"expiry_extended_at,omitempty"`
+
+	Courier        string     `json:"courier,omitempty"`
+	TrackingNumber string     `json:"tracking_number,omitempty"`
+	ShippedAt      *time.Time `json:"shipped_at,omitempty"`
+
+	DeliveryMethod  DeliveryMethod   `json:"delivery_method"`
+	ShippingAddress *ShippingAddress `json:"shipping_address,omitempty"`
+
+	Pricing OrderPricing `json:"pricing"`
+	// RefundedQuantity and RefundedAmount add up the succeeded refunds.
+	RefundedQuantity int64 `json:"refunded_quantity"`
+	RefundedAmount   int64 `json:"refunded_amount"`
+
+	// Version is incremented by every write and served as the ETag.
+	Version int64 `json:"version"`
+}
+
+// CancelledByExpiry reports whether the order was cancelled once its payment
+// window had passed, as opposed to cancelled on purpose before that.
+// Cancelled is final, so UpdatedAt is the time of the cancellation.
+func (o Order) CancelledByExpiry() bool {
+	return o.Status == OrderStatusCancelled && !o.UpdatedAt.Before(o.ExpiredAt)
+}
+
+// LogValue keeps user supplied free text out of the logs.
+func (o Order) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int64("id", o.ID),
+		slog.Int64("product_id", o.ProductID),
+		slog.Int64("quantity", o.Quantity),
+		slog.Int64("user_id", o.UserID),
+		slog.Int64("shop_id", o.ShopID),
+		slog.String("status", string(o.Status)),
+		slog.Bool("has_notes", o.Notes != ""),
+		slog.String("delivery_method", string(o.DeliveryMethod)),
+		slog.Int64("total", o.Pricing.Total),
+		slog.Time("expired_at", o.ExpiredAt),
+		slog.Int64("version", o.Version),
+	)
+}
+
+func (r OrderCreateRequest) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int64("shop_id", r.ShopID),
+		slog.Int64("product_id", r.ProductID),
+		slog.Int64("quantity", r.Quantity),
+		slog.Bool("has_notes", r.Notes != ""),
+		slog.String("delivery_method", string(r.DeliveryMethod)),
+		slog.Bool("has_shipping_address", r.ShippingAddress != nil),
+		slog.Bool("has_coupon", r.CouponCode != ""),
+		slog.Bool("has_quote", r.QuoteToken != ""),
+	)
 }
 
 type OrderUpdateStatusRequest struct {
-	OrderID int64  `json:"order_id"`
+	OrderID int64  `json:"order_id" validate:"required,id"`
 	Status  string `json:"status" validate:"required,oneof=paid cancelled"`
 }
 
 type OrderCreateRequest struct {
-	ProductID int64 `json:"product_id"`
-	Quantity  int64 `json:"quantity"`
+	// ShopID is optional, the shop is taken from the product. When given it
+	// must be the shop of the product.
+	ShopID    int64  `json:"shop_id" validate:"omitempty,id"`
+	ProductID int64  `json:"product_id" validate:"required,id"`
+	Quantity  int64  `json:"quantity" validate:"required,order_qty"`
+	Notes     string `json:"notes" validate:"omitempty,notes"`
+	OrderShipping
+	CouponCode string `json:"coupon_code" validate:"omitempty,coupon_code"`
+	// QuoteToken holds the prices of an earlier quote for the same request.
+	// It is ignored by QuoteOrder.
+	QuoteToken string `json:"quote_token" validate:"omitempty,max=2048"`
+}
+
+// OrderUpdateRequest edits an unpaid order. Quantity zero removes the line
+// and gives its reserved stock back, but an order keeps at least one line, so
+// removing its last line is refused with ErrLastOrderLine.
+type OrderUpdateRequest struct {
+	Quantity *int64 `json:"quantity" validate:"required,eq=0|order_qty"`
+	// ResetExpiry restarts the payment window from now. It counts as the one
+	// extension of the order and is refused for high demand products.
+	ResetExpiry bool `json:"reset_expiry"`
+}
+
+// OrderExtendRequest extends the payment window of an unpaid order. Zero
+// extends it by the configured maximum.
+type OrderExtendRequest struct {
+	ExtendSeconds int64 `json:"extend_seconds" validate:"gte=0"`
+}
+
+type OrderRejectRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type OrderShipRequest struct {
+	Courier        string `json:"courier" validate:"required,max=50"`
+	TrackingNumber string `json:"tracking_number" validate:"required,max=100"`
+}
+
+// UnpaidOrderStats summarizes the waiting_payment orders of a user.
+type UnpaidOrderStats struct {
+	OrderCount int64
+	// ProductQuantity is the quantity reserved for one product.
+	ProductQuantity int64
+}
+
+// AdminOrderActionRequest carries the mandatory reason of an admin override.
+type AdminOrderActionRequest struct {
+	Reason string `json:"reason" validate:"required,min=5,max=255"`
+}
+
+// OrderFilter narrows order listings. CreatedFrom and CreatedTo are dates in
+// YYYY-MM-DD format, both inclusive.
+type OrderFilter struct {
+	Status      string `query:"status" validate:"omitempty,oneof=waiting_payment paid cancelled accepted shipped refund_pending completed refund_required refunded"`
+	ProductID   int64  `query:"product_id" validate:"omitempty,id"`
+	UserID      int64  `query:"user_id" validate:"omitempty,id"`
+	ShopID      int64  `query:"shop_id" validate:"omitempty,id"`
+	CreatedFrom string `query:"created_from" validate:"omitempty,datetime=2006-01-02"`
+	CreatedTo   string `query:"created_to" validate:"omitempty,datetime=2006-01-02"`
+	Page        int    `query:"page" validate:"omitempty,gte=1"`
+	Limit       int    `query:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+const DefaultPageLimit = 20
+
+// Normalize fills in the paging defaults.
+func (f *OrderFilter) Normalize() {
+	if f.Page == 0 {
+		f.Page = 1
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultPageLimit
+	}
 }
 
 type OrderRepository interface {
 	CreateOrder(ctx context.Context, order *Order, tx *sql.Tx) error
 	GetOrderByID(ctx context.Context, id int64) (Order, error)
-	UpdateStatusOrder(ctx context.Context, id int64, status string, tx *sql.Tx) error
+	GetOrderByIDForUpdate(ctx context.Context, id int64, tx *sql.Tx) (Order, error)
+	// UpdateStatusOrder and UpdateShipment return ErrVersionConflict when the
+	// order is no longer at version.
+	UpdateStatusOrder(ctx context.Context, id, version int64, status string, tx *sql.Tx) error
+	UpdateShipment(ctx context.Context, id, version int64, req OrderShipRequest, tx *sql.Tx) error
+	// UpdateShipping replaces the delivery method, the shipping address and
+	// the pricing that depends on them, removing the address when order has
+	// none.
+	UpdateShipping(ctx context.Context, id, version int64, order Order, tx *sql.Tx) error
+	// UpdateQuantity stores the new quantity, pricing, tax lines and payment
+	// window of an unpaid order.
+	UpdateQuantity(ctx context.Context, id, version int64, order Order, tx *sql.Tx) error
+	// ApplyRefund adds a succeeded refund to the refunded quantity and amount
+	// and moves the order to status.
+	ApplyRefund(ctx context.Context, id, version int64, refund Refund, status OrderStatus, tx *sql.Tx) error
+	// ExtendExpiry moves the payment window of an order to expiredAt and marks
+	// it as extended.
+	ExtendExpiry(ctx context.Context, id, version int64, expiredAt time.Time, tx *sql.Tx) error
+	CreateOrderHistory(ctx context.Context, history *OrderHistory, tx *sql.Tx) error
+	// LockUserOrders serializes order creation of a user until tx ends.
+	LockUserOrders(ctx context.Context, userID int64, tx *sql.Tx) error
+	GetUnpaidOrderStats(ctx context.Context, userID, productID int64, tx *sql.Tx) (UnpaidOrderStats, error)
 	GetListByUserID(ctx context.Context, userID int64) ([]Order, error)
+	GetListByShopID(ctx context.Context, shopID int64, filter OrderFilter) ([]Order, error)
+	SearchOrders(ctx context.Context, filter OrderFilter) ([]Order, error)
 	GetExpiredOrders(ctx context.Context) ([]Order, error)
 
 	WithTransaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error
@@ -47,8 +243,49 @@ type OrderRepository interface {
 
 type OrderUsecase interface {
 	CreateOrder(ctx context.Context, userID int64, req OrderCreateRequest) (Order, error)
+	// QuoteOrder runs the checks and the pricing of CreateOrder without
+	// creating the order.
+	QuoteOrder(ctx context.Context, userID int64, req OrderCreateRequest) (OrderQuote, error)
+	// UpdateStatusOrder applies a payment callback. Like HandlePaymentEvent it
+	// treats a paid result for a cancelled order as a late payment: the order
+	// is paid when its stock can be reserved again, otherwise it becomes
+	// refund_required.
 	UpdateStatusOrder(ctx context.Context, req OrderUpdateStatusRequest) error
 	GetListByUserID(ctx context.Context, userID int64) ([]Order, error)
 	GetOrderByID(ctx context.Context, userID int64, id int64) (Order, error)
+	// UpdateShipping changes the delivery details of an unpaid order of the user.
+	UpdateShipping(ctx context.Context, userID, id int64, req OrderShipping) (Order, error)
+	// UpdateOrder changes the quantity of an unpaid order of the user and
+	// its reservation, the order is unchanged when the stock is short.
+	UpdateOrder(ctx context.Context, userID, id int64, req OrderUpdateRequest) (Order, error)
+	// ExtendOrderExpiry extends the payment window and the reservation of an
+	// unpaid order of the user, once per order.
+	ExtendOrderExpiry(ctx context.Context, userID, id int64, req OrderExtendRequest) (Order, error)
+	GetListByShopID(ctx context.Context, shopID int64, filter OrderFilter) ([]Order, error)
+	GetShopOrderByID(ctx context.Context, shopID int64, id int64) (Order, error)
+	AcceptOrder(ctx context.Context, shopID, sellerID, id int64) (Order, error)
+	RejectOrder(ctx context.Context, shopID, sellerID, id int64, req OrderRejectRequest) (Order, error)
+	ShipOrder(ctx context.Context, shopID, sellerID, id int64, req OrderShipRequest) (Order, error)
+	AdminSearchOrders(ctx context.Context, filter OrderFilter) ([]Order, error)
+	AdminGetOrderByID(ctx context.Context, id int64) (Order, error)
+	ForceCancelOrder(ctx context.Context, adminID, id int64, req AdminOrderActionRequest) (Order, error)
+	ForceCompleteOrder(ctx context.Context, adminID, id int64, req AdminOrderActionRequest) (Order, error)
+	AdminListLatePayments(ctx context.Context, filter LatePaymentFilter) ([]LatePayment, error)
+	// RequestShopRefund and RequestAdminRefund create a pending refund that
+	// the payment provider confirms through ConfirmRefund.
+	RequestShopRefund(ctx context.Context, shopID, sellerID, id int64, req RefundCreateRequest) (Refund, error)
+	RequestAdminRefund(ctx context.Context, adminID, id int64, req RefundCreateRequest) (Refund, error)
+	GetShopOrderRefunds(ctx context.Context, shopID, id int64) ([]Refund, error)
+	AdminGetOrderRefunds(ctx context.Context, id int64) ([]Refund, error)
+	// ConfirmRefund applies the outcome of a refund. A succeeded refund is
+	// added to the refunded quantity of the order, which becomes refunded once
+	// every unit is, and its stock is returned.
+	ConfirmRefund(ctx context.Context, req RefundConfirmRequest) (Refund, error)
 	UpdateExpiredOrders(ctx context.Context)
+	// HandlePaymentEvent applies a payment result received from the broker,
+	// status is paid or cancelled. Redeliveries of msg are ignored.
+	HandlePaymentEvent(ctx context.Context, msg Message, status OrderStatus, payload PaymentEventPayload) error
+	// HandleStockReleased cancels an unpaid order whose reservation was
+	// released by the warehouse. Redeliveries of msg are ignored.
+	HandleStockReleased(ctx context.Context, msg Message, payload StockReleasedPayload) error
 }
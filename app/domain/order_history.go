@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+type ActorType string
+
+const (
+	ActorTypeUser    ActorType = "user"
+	ActorTypeSeller  ActorType = "seller"
+	ActorTypePayment ActorType = "payment"
+	ActorTypeSystem  ActorType = "system"
+	ActorTypeAdmin   ActorType = "admin"
+)
+
+// OrderHistory records a change made to an order and who made it.
+type OrderHistory struct {
+	ID         int64       `json:"id"`
+	OrderID    int64       `json:"order_id"`
+	FromStatus OrderStatus `json:"from_status"`
+	ToStatus   OrderStatus `json:"to_status"`
+	ActorType  ActorType   `json:"actor_type"`
+	ActorID    int64       `json:"actor_id"`
+	Note       string      `json:"note,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
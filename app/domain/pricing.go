@@ -0,0 +1,18 @@
+package domain
+
+// OrderPricing is fixed when an order is created, later price changes do not
+// affect it. Amounts are in the minor unit of the currency.
+type OrderPricing struct {
+	UnitPrice   int64 `json:"unit_price"`
+	Subtotal    int64 `json:"subtotal"`
+	ShippingFee int64 `json:"shipping_fee"`
+	// Discount is what the coupon took off the items and the shipping fee,
+	// broken down in Coupon.
+	Discount int64             `json:"discount"`
+	Coupon   *CouponRedemption `json:"coupon,omitempty"`
+	// Tax is the sum of the exclusive tax lines, inclusive taxes are part of
+	// the prices already.
+	Tax      int64     `json:"tax"`
+	TaxLines []TaxLine `json:"tax_lines"`
+	Total    int64     `json:"total"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "context"
+
+// Product is the catalog data an order is priced with. Price is in the minor
+// unit of the currency, Category selects the tax rate. HighDemand products
+// cannot hold their stock longer than the usual payment window.
+type Product struct {
+	ID         int64  `json:"id"`
+	ShopID     int64  `json:"shop_id"`
+	Price      int64  `json:"price"`
+	Category   string `json:"category"`
+	HighDemand bool   `json:"high_demand"`
+}
+
+type ProductRepository interface {
+	GetProductByID(ctx context.Context, id int64) (Product, error)
+}
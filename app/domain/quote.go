@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// OrderQuote is what CreateOrder would do with a request, computed without
+// writing anything or reserving stock. Token holds the quoted prices for
+// CreateOrder until TokenExpiresAt, it is empty when the stock is short or
+// quote tokens are disabled.
+type OrderQuote struct {
+	ShopID         int64          `json:"shop_id"`
+	ProductID      int64          `json:"product_id"`
+	Quantity       int64          `json:"quantity"`
+	DeliveryMethod DeliveryMethod `json:"delivery_method"`
+	Pricing        OrderPricing   `json:"pricing"`
+	AvailableStock int64          `json:"available_stock"`
+	Available      bool           `json:"available"`
+	// ExpiredAt is when the payment window of an order created now would end.
+	ExpiredAt      time.Time  `json:"expired_at"`
+	Token          string     `json:"token,omitempty"`
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
+}
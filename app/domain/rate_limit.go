@@ -0,0 +1,16 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimitStore keeps fixed window request counters. The postgres store
+// shares counters between replicas, the memory store is for a single node.
+type RateLimitStore interface {
+	// Increment counts a hit for key in the window containing now and returns
+	// the hit count of that window and when the window ends.
+	Increment(ctx context.Context, key string, window time.Duration) (int64, time.Time, error)
+	// Cleanup removes counters of windows that have ended.
+	Cleanup(ctx context.Context) error
+}
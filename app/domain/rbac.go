@@ -0,0 +1,45 @@
+package domain
+
+type Permission string
+
+const (
+	PermissionOrderRead  Permission = "orders:read"
+	PermissionOrderWrite Permission = "orders:write"
+	PermissionAuditRead  Permission = "audit:read"
+	// PermissionWebhookManage covers webhook subscriptions and their deliveries.
+	PermissionWebhookManage Permission = "webhooks:manage"
+	PermissionCouponManage  Permission = "coupons:manage"
+)
+
+type Role string
+
+const (
+	RoleAdmin   Role = "admin"
+	RoleSupport Role = "support"
+	RoleAuditor Role = "auditor"
+)
+
+// rolePermissions grants permissions to roles carried in the token. Tokens
+// may also carry individual permissions in the perms claim.
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin:   {PermissionOrderRead, PermissionOrderWrite, PermissionAuditRead, PermissionWebhookManage, PermissionCouponManage},
+	RoleSupport: {PermissionOrderRead},
+	RoleAuditor: {PermissionAuditRead},
+}
+
+// HasPermission reports whether the roles or the explicit permissions grant p.
+func HasPermission(roles, perms []string, p Permission) bool {
+	for _, perm := range perms {
+		if Permission(perm) == p {
+			return true
+		}
+	}
+	for _, role := range roles {
+		for _, perm := range rolePermissions[Role(role)] {
+			if perm == p {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusSucceeded RefundStatus = "succeeded"
+	RefundStatusFailed    RefundStatus = "failed"
+)
+
+// RefundStockAction is what happened to the stock of the refunded quantity.
+// It is decided when the refund succeeds, since the order may have been
+// shipped while the refund was pending.
+type RefundStockAction string
+
+const (
+	// RefundStockRelease gives back part of the reservation of an order that
+	// has not been shipped.
+	RefundStockRelease RefundStockAction = "release"
+	// RefundStockRestock puts shipped units back on the shelf.
+	RefundStockRestock RefundStockAction = "restock"
+	// RefundStockNone is used when the order holds no stock anymore, like a
+	// rejected order or a late payment.
+	RefundStockNone RefundStockAction = "none"
+)
+
+// Refund is money going back to the customer for Quantity units of an order.
+// Amount is in the minor unit of the payment currency and is passed to the
+// payment provider as is.
+type Refund struct {
+	ID                int64             `json:"id"`
+	OrderID           int64             `json:"order_id"`
+	Quantity          int64             `json:"quantity"`
+	Amount            int64             `json:"amount,omitempty"`
+	Reason            string            `json:"reason"`
+	Status            RefundStatus      `json:"status"`
+	StockAction       RefundStockAction `json:"stock_action,omitempty"`
+	RequestedByType   ActorType         `json:"requested_by_type"`
+	RequestedByID     int64             `json:"requested_by_id"`
+	ProviderReference string            `json:"provider_reference,omitempty"`
+	FailureReason     string            `json:"failure_reason,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+	ConfirmedAt       *time.Time        `json:"confirmed_at,omitempty"`
+}
+
+// RefundCreateRequest asks for a refund, the whole quantity that is not
+// refunded yet when Quantity is zero. Amount defaults to the share of the
+// order total for Quantity and may not exceed what is left of the total.
+type RefundCreateRequest struct {
+	Quantity int64  `json:"quantity" validate:"omitempty,gte=1"`
+	Amount   int64  `json:"amount" validate:"omitempty,gte=1"`
+	Reason   string `json:"reason" validate:"required,min=5,max=255"`
+}
+
+// RefundConfirmRequest is sent by the payment provider once a refund has
+// been paid out or has failed.
+type RefundConfirmRequest struct {
+	RefundID          int64  `json:"refund_id" validate:"required,id"`
+	Status            string `json:"status" validate:"required,oneof=succeeded failed"`
+	ProviderReference string `json:"provider_reference" validate:"omitempty,max=100"`
+	Reason            string `json:"reason" validate:"omitempty,max=255"`
+}
+
+// RefundSummary adds up the refunds of an order. Requested counts pending and
+// succeeded refunds, so the same units cannot be refunded twice.
+type RefundSummary struct {
+	Requested int64
+	Refunded  int64
+	// RequestedAmount adds up the amounts of the pending and succeeded refunds.
+	RequestedAmount int64
+}
+
+type RefundRepository interface {
+	CreateRefund(ctx context.Context, refund *Refund, tx *sql.Tx) error
+	GetRefundByIDForUpdate(ctx context.Context, id int64, tx *sql.Tx) (Refund, error)
+	GetRefundSummary(ctx context.Context, orderID int64, tx *sql.Tx) (RefundSummary, error)
+	UpdateRefundStatus(ctx context.Context, refund *Refund, tx *sql.Tx) error
+	ListRefundsByOrderID(ctx context.Context, orderID int64) ([]Refund, error)
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"log/slog"
+	"strings"
+)
+
+type DeliveryMethod string
+
+const (
+	DeliveryMethodStandard DeliveryMethod = "standard"
+	DeliveryMethodExpress  DeliveryMethod = "express"
+	DeliveryMethodSameDay  DeliveryMethod = "same_day"
+	// DeliveryMethodPickup orders are collected at the shop and have no
+	// shipping address.
+	DeliveryMethodPickup DeliveryMethod = "pickup"
+)
+
+// ShippingAddress is where the warehouse sends an order. Country is an ISO
+// 3166-1 alpha-2 code.
+type ShippingAddress struct {
+	RecipientName string `json:"recipient_name" validate:"required,address_text,max=100"`
+	Phone         string `json:"phone" validate:"required,phone"`
+	AddressLine1  string `json:"address_line1" validate:"required,address_text,max=200"`
+	AddressLine2  string `json:"address_line2,omitempty" validate:"omitempty,address_text,max=200"`
+	City          string `json:"city" validate:"required,address_text,max=100"`
+	PostalCode    string `json:"postal_code" validate:"required,postal_code"`
+	Country       string `json:"country" validate:"required,iso3166_1_alpha2"`
+}
+
+// Normalize trims the free text fields and upper cases the codes, so the
+// same address is always stored the same way.
+func (a *ShippingAddress) Normalize() {
+	a.RecipientName = strings.TrimSpace(a.RecipientName)
+	a.Phone = strings.TrimSpace(a.Phone)
+	a.AddressLine1 = strings.TrimSpace(a.AddressLine1)
+	a.AddressLine2 = strings.TrimSpace(a.AddressLine2)
+	a.City = strings.TrimSpace(a.City)
+	a.PostalCode = strings.ToUpper(strings.TrimSpace(a.PostalCode))
+	a.Country = strings.ToUpper(strings.TrimSpace(a.Country))
+}
+
+// LogValue keeps personal data out of the logs.
+func (a ShippingAddress) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("city", a.City),
+		slog.String("country", a.Country),
+	)
+}
+
+// OrderShipping is the delivery part of an order request. The address is
+// required unless the order is picked up, and must be left out then.
+type OrderShipping struct {
+	DeliveryMethod  DeliveryMethod   `json:"delivery_method" validate:"omitempty,oneof=standard express same_day pickup"`
+	ShippingAddress *ShippingAddress `json:"shipping_address" validate:"required_unless=DeliveryMethod pickup,excluded_if=DeliveryMethod pickup"`
+}
+
+// Normalize fills in the standard delivery method and normalizes the address.
+func (s *OrderShipping) Normalize() {
+	if s.DeliveryMethod == "" {
+		s.DeliveryMethod = DeliveryMethodStandard
+	}
+	if s.ShippingAddress != nil {
+		s.ShippingAddress.Normalize()
+	}
+}
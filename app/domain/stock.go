@@ -2,9 +2,19 @@ package domain
 
 import (
 	"context"
+	"time"
+)
+
+// Reserved stock statuses understood by the warehouse service.
+const (
+	ReservedStockStatusCompleted = "completed"
+	ReservedStockStatusCancelled = "cancelled"
+	// ReservedStockStatusReleased returns the stock of a completed reservation.
+	ReservedStockStatusReleased = "released"
 )
 
 type ReservedStockCreateRequest struct {
+	ShopID    int64 `json:"shop_id"`
 	ProductID int64 `json:"product_id"`
 	Quantity  int64 `json:"quantity"`
 	OrderID   int64 `json:"order_id"`
@@ -14,7 +24,34 @@ type ReservedStockUpdateRequest struct {
 	Status string `json:"status"`
 }
 
+// ReservedStockReleaseRequest gives back part of a completed reservation.
+type ReservedStockReleaseRequest struct {
+	Quantity int64 `json:"quantity"`
+}
+
+// ReservedStockAdjustRequest changes the quantity of the reservation of an
+// unpaid order. ExpiredAt is set when the order's payment window restarts.
+type ReservedStockAdjustRequest struct {
+	Quantity  int64      `json:"quantity"`
+	ExpiredAt *time.Time `json:"expired_at,omitempty"`
+}
+
+// RestockRequest puts units that were shipped for an order back in stock.
+type RestockRequest struct {
+	ShopID    int64 `json:"shop_id"`
+	ProductID int64 `json:"product_id"`
+	Quantity  int64 `json:"quantity"`
+	OrderID   int64 `json:"order_id"`
+}
+
 type StockRepository interface {
+	GetAvailableStock(ctx context.Context, productID int64) (int64, error)
 	CreateReservedStock(ctx context.Context, req ReservedStockCreateRequest) error
 	UpdateReservedStockStatus(ctx context.Context, orderID int64, req ReservedStockUpdateRequest) error
+	ReleaseReservedStock(ctx context.Context, orderID int64, req ReservedStockReleaseRequest) error
+	// AdjustReservedStock reserves more or gives back units of the pending
+	// reservation of an order, it fails without change when the stock is short.
+	// With ExpiredAt set the reservation is held until then.
+	AdjustReservedStock(ctx context.Context, orderID int64, req ReservedStockAdjustRequest) error
+	Restock(ctx context.Context, req RestockRequest) error
 }
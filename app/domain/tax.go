@@ -0,0 +1,42 @@
+package domain
+
+import "context"
+
+// TaxComponent is the part of an order a tax line is charged on.
+type TaxComponent string
+
+const (
+	TaxComponentItems    TaxComponent = "items"
+	TaxComponentShipping TaxComponent = "shipping"
+)
+
+// TaxCategoryShipping is the category shipping fees are taxed under.
+const TaxCategoryShipping = "shipping"
+
+// TaxLine is a tax charged on an order. RateBasisPoints is the rate in
+// hundredths of a percent, 1100 is 11%. An inclusive tax is part of the
+// taxable amount already and is not added to the total.
+type TaxLine struct {
+	Component       TaxComponent `json:"component"`
+	Name            string       `json:"name"`
+	Region          string       `json:"region"`
+	Category        string       `json:"category"`
+	RateBasisPoints int64        `json:"rate_bps"`
+	Inclusive       bool         `json:"inclusive"`
+	TaxableAmount   int64        `json:"taxable_amount"`
+	Amount          int64        `json:"amount"`
+}
+
+// TaxRequest describes what an order is taxed on. Region is the country of
+// the shipping address, empty for pickup orders. The amounts are after
+// discounts.
+type TaxRequest struct {
+	Region         string
+	Category       string
+	ItemAmount     int64
+	ShippingAmount int64
+}
+
+type TaxCalculator interface {
+	Calculate(ctx context.Context, req TaxRequest) ([]TaxLine, error)
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type WebhookSubscription struct {
+	ID         int64            `json:"id"`
+	URL        string           `json:"url"`
+	EventTypes []OrderEventType `json:"event_types"`
+	Active     bool             `json:"active"`
+	// Secret signs the payloads, it is returned only when the subscription is created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WebhookSubscriptionRequest struct {
+	URL        string   `json:"url" validate:"required,url,startswith=http,max=2048"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=order.created order.paid order.cancelled order.expired order.refund_required order.refund_requested order.refunded"`
+	Active     *bool    `json:"active"`
+	// Secret is generated when empty.
+	Secret string `json:"secret" validate:"omitempty,min=16,max=128"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryFailed is set once all attempts are used, only a manual
+	// replay sends the delivery again.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+type WebhookDelivery struct {
+	ID             int64                 `json:"id"`
+	SubscriptionID int64                 `json:"subscription_id"`
+	EventID        string                `json:"event_id"`
+	EventType      OrderEventType        `json:"event_type"`
+	Payload        json.RawMessage       `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	NextAttemptAt  time.Time             `json:"next_attempt_at"`
+	LastStatusCode int                   `json:"last_status_code,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+
+	// URL and Secret of the subscription, filled in for dispatching only.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+type WebhookDeliveryFilter struct {
+	SubscriptionID int64  `query:"subscription_id" validate:"omitempty,id"`
+	Status         string `query:"status" validate:"omitempty,oneof=pending succeeded failed"`
+	EventType      string `query:"event_type" validate:"omitempty,oneof=order.created order.paid order.cancelled order.expired order.refund_required order.refund_requested order.refunded"`
+	Page           int    `query:"page" validate:"omitempty,gte=1"`
+	Limit          int    `query:"limit" validate:"omitempty,gte=1,lte=100"`
+}
+
+// Normalize fills in the paging defaults.
+func (f *WebhookDeliveryFilter) Normalize() {
+	if f.Page == 0 {
+		f.Page = 1
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultPageLimit
+	}
+}
+
+type WebhookRepository interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error
+	CreateSubscription(ctx context.Context, sub *WebhookSubscription, tx *sql.Tx) error
+	GetSubscriptionByID(ctx context.Context, id int64) (WebhookSubscription, error)
+	GetSubscriptionByIDForUpdate(ctx context.Context, id int64, tx *sql.Tx) (WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, sub *WebhookSubscription, tx *sql.Tx) error
+	DeleteSubscription(ctx context.Context, id int64, tx *sql.Tx) error
+
+	// EnqueueDeliveries queues the event for every active subscription of its
+	// type, within the transaction of the change that produced it.
+	EnqueueDeliveries(ctx context.Context, event OrderEvent, payload []byte, tx *sql.Tx) error
+	// ClaimDueDeliveries returns pending deliveries that are due and pushes
+	// their next attempt back by lease, so other workers skip them meanwhile.
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDelivery, error)
+	MarkDeliverySucceeded(ctx context.Context, id int64, statusCode int) error
+	// MarkDeliveryFailed records a failed attempt, a nil nextAttemptAt marks
+	// the delivery as failed for good.
+	MarkDeliveryFailed(ctx context.Context, id int64, statusCode int, lastError string, nextAttemptAt *time.Time) error
+	ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error)
+	GetDeliveryByIDForUpdate(ctx context.Context, id int64, tx *sql.Tx) (WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, id int64, tx *sql.Tx) error
+}
+
+// WebhookSender posts a signed payload to a subscriber.
+type WebhookSender interface {
+	Send(ctx context.Context, url string, header http.Header, body []byte) (int, error)
+}
+
+type WebhookUsecase interface {
+	CreateSubscription(ctx context.Context, adminID int64, req WebhookSubscriptionRequest) (WebhookSubscription, error)
+	GetSubscriptionByID(ctx context.Context, id int64) (WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, adminID, id int64, req WebhookSubscriptionRequest) (WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, adminID, id int64) error
+	ListDeliveries(ctx context.Context, filter WebhookDeliveryFilter) ([]WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, adminID, id int64) error
+	DispatchDueDeliveries(ctx context.Context)
+}
@@ -0,0 +1,212 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/config"
+	"order-service/pkg/ctxutil"
+	orderv1 "order-service/pkg/pb/order/v1"
+	"order-service/pkg/validation"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// OrderServer is the gRPC counterpart of the admin order endpoints, for
+// internal services.
+type OrderServer struct {
+	orderv1.UnimplementedOrderServiceServer
+
+	OrderUsecase domain.OrderUsecase
+	validator    *validator.Validate
+	cfg          *config.Store
+}
+
+func NewOrderServer(orderUsecase domain.OrderUsecase, validator *validator.Validate, cfg *config.Store) *OrderServer {
+	return &OrderServer{
+		OrderUsecase: orderUsecase,
+		validator:    validator,
+		cfg:          cfg,
+	}
+}
+
+func (s *OrderServer) GetOrder(ctx context.Context, req *orderv1.GetOrderRequest) (*orderv1.Order, error) {
+	if req.GetId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "id must be a positive number")
+	}
+
+	order, err := s.OrderUsecase.AdminGetOrderByID(ctx, req.GetId())
+	if err != nil {
+		slog.ErrorContext(ctx, "[OrderServer] GetOrder", "usecase", err)
+		return nil, toStatus(err)
+	}
+	return toProtoOrder(order), nil
+}
+
+func (s *OrderServer) ListOrders(ctx context.Context, req *orderv1.ListOrdersRequest) (*orderv1.ListOrdersResponse, error) {
+	filter := domain.OrderFilter{
+		Status:      req.GetStatus(),
+		ProductID:   req.GetProductId(),
+		UserID:      req.GetUserId(),
+		ShopID:      req.GetShopId(),
+		CreatedFrom: req.GetCreatedFrom(),
+		CreatedTo:   req.GetCreatedTo(),
+		Page:        int(req.GetPage()),
+		Limit:       int(req.GetLimit()),
+	}
+	if err := s.validator.Struct(filter); err != nil {
+		slog.ErrorContext(ctx, "[OrderServer] ListOrders", "validation", err)
+		return nil, toStatus(validation.Translate(err))
+	}
+
+	orders, err := s.OrderUsecase.AdminSearchOrders(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "[OrderServer] ListOrders", "usecase", err)
+		return nil, toStatus(err)
+	}
+
+	res := &orderv1.ListOrdersResponse{Orders: make([]*orderv1.Order, 0, len(orders))}
+	for _, order := range orders {
+		res.Orders = append(res.Orders, toProtoOrder(order))
+	}
+	return res, nil
+}
+
+func (s *OrderServer) UpdateOrderStatus(ctx context.Context, req *orderv1.UpdateOrderStatusRequest) (*orderv1.Order, error) {
+	updateReq := domain.OrderUpdateStatusRequest{
+		OrderID: req.GetId(),
+		Status:  req.GetStatus(),
+	}
+	if err := s.validator.Struct(updateReq); err != nil {
+		slog.ErrorContext(ctx, "[OrderServer] UpdateOrderStatus", "validation", err)
+		return nil, toStatus(validation.Translate(err))
+	}
+
+	if req.GetExpectedVersion() > 0 {
+		ctx = ctxutil.WithExpectedVersion(ctx, req.GetExpectedVersion())
+	}
+	if err := s.OrderUsecase.UpdateStatusOrder(ctx, updateReq); err != nil {
+		slog.ErrorContext(ctx, "[OrderServer] UpdateOrderStatus", "usecase", err)
+		return nil, toStatus(err)
+	}
+
+	order, err := s.OrderUsecase.AdminGetOrderByID(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProtoOrder(order), nil
+}
+
+// WatchOrder polls the order and sends it whenever it changed, until the
+// order is final or the client goes away.
+func (s *OrderServer) WatchOrder(req *orderv1.WatchOrderRequest, stream grpc.ServerStreamingServer[orderv1.Order]) error {
+	ctx := stream.Context()
+	if req.GetId() <= 0 {
+		return status.Error(codes.InvalidArgument, "id must be a positive number")
+	}
+
+	ticker := time.NewTicker(time.Duration(s.cfg.Get().Grpc.WatchPollSeconds) * time.Second)
+	defer ticker.Stop()
+
+	var lastVersion int64
+	for {
+		order, err := s.OrderUsecase.AdminGetOrderByID(ctx, req.GetId())
+		if err != nil {
+			slog.ErrorContext(ctx, "[OrderServer] WatchOrder", "usecase", err)
+			return toStatus(err)
+		}
+
+		if order.Version != lastVersion {
+			lastVersion = order.Version
+			if err := stream.Send(toProtoOrder(order)); err != nil {
+				return err
+			}
+		}
+		if order.Status.IsFinal() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// toStatus maps domain errors to gRPC codes, like response.FromError does
+// for HTTP status codes.
+func toStatus(err error) error {
+	var code codes.Code
+	switch {
+	case errors.Is(err, domain.ErrValidation), errors.Is(err, domain.ErrInvalidRequest), errors.Is(err, domain.ErrBadRequest):
+		code = codes.InvalidArgument
+	case errors.Is(err, domain.ErrUnauthorized):
+		code = codes.Unauthenticated
+	case errors.Is(err, domain.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, domain.ErrConflict), errors.Is(err, domain.ErrPreconditionFailed):
+		code = codes.FailedPrecondition
+	case errors.Is(err, domain.ErrTooManyRequest):
+		code = codes.ResourceExhausted
+	case errors.Is(err, domain.ErrNotFound):
+		code = codes.NotFound
+	default:
+		return status.Error(codes.Internal, domain.ErrInternal.Error())
+	}
+	return status.Error(code, err.Error())
+}
+
+func toProtoOrder(order domain.Order) *orderv1.Order {
+	res := &orderv1.Order{
+		Id:             order.ID,
+		ProductId:      order.ProductID,
+		Quantity:       order.Quantity,
+		UserId:         order.UserID,
+		ShopId:         order.ShopID,
+		Status:         string(order.Status),
+		Notes:          order.Notes,
+		CreatedAt:      timestamppb.New(order.CreatedAt),
+		UpdatedAt:      timestamppb.New(order.UpdatedAt),
+		ExpiredAt:      timestamppb.New(order.ExpiredAt),
+		Courier:        order.Courier,
+		TrackingNumber: order.TrackingNumber,
+		Version:        order.Version,
+		DeliveryMethod: string(order.DeliveryMethod),
+		Pricing: &orderv1.OrderPricing{
+			UnitPrice:   order.Pricing.UnitPrice,
+			Subtotal:    order.Pricing.Subtotal,
+			ShippingFee: order.Pricing.ShippingFee,
+			Discount:    order.Pricing.Discount,
+			Tax:         order.Pricing.Tax,
+			Total:       order.Pricing.Total,
+		},
+	}
+	if order.Pricing.Coupon != nil {
+		res.Pricing.CouponCode = order.Pricing.Coupon.Code
+	}
+	if order.ShippedAt != nil {
+		res.ShippedAt = timestamppb.New(*order.ShippedAt)
+	}
+	if order.ExpiryExtendedAt != nil {
+		res.ExpiryExtendedAt = timestamppb.New(*order.ExpiryExtendedAt)
+	}
+	if a := order.ShippingAddress; a != nil {
+		res.ShippingAddress = &orderv1.ShippingAddress{
+			RecipientName: a.RecipientName,
+			Phone:         a.Phone,
+			AddressLine1:  a.AddressLine1,
+			AddressLine2:  a.AddressLine2,
+			City:          a.City,
+			PostalCode:    a.PostalCode,
+			Country:       a.Country,
+		}
+	}
+	return res
+}
@@ -0,0 +1,158 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/config"
+	"order-service/pkg/ctxutil"
+	orderv1 "order-service/pkg/pb/order/v1"
+	"os"
+
+	"github.com/gofrs/uuid/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys, gRPC lowercases every key.
+const (
+	internalAuthKey = "x-internal-auth"
+	requestIDKey    = "x-request-id"
+)
+
+// NewServer builds the gRPC server with the order service registered. In
+// mtls mode the TLS handshake already rejects clients without a valid
+// certificate, otherwise every call is checked against the internal secret.
+// The auth mode is read once, it has to match the transport built here.
+func NewServer(orderServer *OrderServer, cfgStore *config.Store) (*grpc.Server, error) {
+	cfg := cfgStore.Get().Grpc
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryInterceptor(cfgStore, cfg.AuthMode)),
+		grpc.ChainStreamInterceptor(streamInterceptor(cfgStore, cfg.AuthMode)),
+	}
+	if cfg.AuthMode == "mtls" {
+		creds, err := mtlsCredentials(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	server := grpc.NewServer(opts...)
+	orderv1.RegisterOrderServiceServer(server, orderServer)
+	return server, nil
+}
+
+func mtlsCredentials(cfg config.GrpcConfig) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load grpc certificate: %w", err)
+	}
+
+	caPEM, err := os.ReadFile(cfg.TLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read grpc client CA: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("grpc client CA file has no certificates")
+	}
+
+	return credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}), nil
+}
+
+func unaryInterceptor(cfgStore *config.Store, authMode string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, cfgStore, authMode)
+		if err != nil {
+			slog.ErrorContext(ctx, "[grpcserver] authenticate", "method", info.FullMethod, "error", err)
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// wrappedStream replaces the context of a server stream.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *wrappedStream) Context() context.Context {
+	return s.ctx
+}
+
+func streamInterceptor(cfgStore *config.Store, authMode string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), cfgStore, authMode)
+		if err != nil {
+			slog.ErrorContext(ctx, "[grpcserver] authenticate", "method", info.FullMethod, "error", err)
+			return err
+		}
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authenticate checks the caller and adds the request ID and source IP used
+// by the logs and the audit log. authMode is the mode the server was built
+// with, not the reloadable setting.
+func authenticate(ctx context.Context, cfgStore *config.Store, authMode string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	reqID := first(md, requestIDKey)
+	if reqID == "" {
+		if id, err := uuid.NewV4(); err == nil {
+			reqID = id.String()
+		}
+	}
+	ctx = ctxutil.WithRequestID(ctx, reqID)
+	p, ok := peer.FromContext(ctx)
+	if ok && p.Addr != nil {
+		ctx = ctxutil.WithSourceIP(ctx, p.Addr.String())
+	}
+
+	if authMode == "mtls" {
+		// the handshake verified the chain, this only guards against a
+		// listener that was not built with the TLS credentials
+		if !ok || !verifiedClientCert(p.AuthInfo) {
+			return ctx, status.Error(codes.Unauthenticated, domain.ErrUnauthorized.Error())
+		}
+		return ctx, nil
+	}
+
+	secret := first(md, internalAuthKey)
+	expected := cfgStore.Get().InternalAuthHeader
+	if secret == "" || expected == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) != 1 {
+		return ctx, status.Error(codes.Unauthenticated, domain.ErrUnauthorized.Error())
+	}
+	return ctx, nil
+}
+
+// verifiedClientCert reports whether the connection is TLS with a client
+// certificate chain verified during the handshake.
+func verifiedClientCert(info credentials.AuthInfo) bool {
+	tlsInfo, ok := info.(credentials.TLSInfo)
+	return ok && len(tlsInfo.State.VerifiedChains) > 0
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"order-service/config"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthenticate(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}
+	plaintext := &peer.Peer{Addr: addr}
+	unverified := &peer.Peer{Addr: addr, AuthInfo: credentials.TLSInfo{}}
+	verified := &peer.Peer{Addr: addr, AuthInfo: credentials.TLSInfo{
+		State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}},
+	}}
+
+	tests := []struct {
+		name     string
+		authMode string
+		// the reloadable setting, which must not change the check
+		cfgMode string
+		secret  string
+		peer    *peer.Peer
+		wantErr bool
+	}{
+		{name: "secret", authMode: "secret", cfgMode: "secret", secret: "internal", peer: plaintext},
+		{name: "wrong secret", authMode: "secret", cfgMode: "secret", secret: "other", peer: plaintext, wantErr: true},
+		{name: "missing secret", authMode: "secret", cfgMode: "secret", peer: plaintext, wantErr: true},
+		{name: "reloaded to mtls", authMode: "secret", cfgMode: "mtls", peer: plaintext, wantErr: true},
+		{name: "mtls verified", authMode: "mtls", cfgMode: "mtls", peer: verified},
+		{name: "mtls unverified", authMode: "mtls", cfgMode: "mtls", peer: unverified, wantErr: true},
+		{name: "mtls plaintext", authMode: "mtls", cfgMode: "mtls", peer: plaintext, wantErr: true},
+		{name: "mtls without peer", authMode: "mtls", cfgMode: "mtls", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfgStore := config.NewStore(&config.Config{InternalAuthHeader: "internal", Grpc: config.GrpcConfig{AuthMode: tt.cfgMode}})
+			ctx := context.Background()
+			if tt.peer != nil {
+				ctx = peer.NewContext(ctx, tt.peer)
+			}
+			if tt.secret != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(internalAuthKey, tt.secret))
+			}
+
+			_, err := authenticate(ctx, cfgStore, tt.authMode)
+			if tt.wantErr {
+				if status.Code(err) != codes.Unauthenticated {
+					t.Fatalf("err = %v, want Unauthenticated", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
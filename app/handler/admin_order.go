@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/app/handler/response"
+	"order-service/pkg/ctxutil"
+	"order-service/pkg/validation"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *OrderHandler) AdminSearchOrders(c *fiber.Ctx) error {
+	var filter domain.OrderFilter
+	if err := c.QueryParser(&filter); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] AdminSearchOrders", "query", err)
+		status, response := response.FromError(fmt.Errorf("%w: %v", domain.ErrBadRequest, err))
+		return c.Status(status).JSON(response)
+	}
+	if err := h.validator.Struct(filter); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] AdminSearchOrders", "validation", err)
+		status, response := response.FromError(validation.Translate(err))
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.OrderUsecase.AdminSearchOrders(c.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] AdminSearchOrders", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
+
+// AdminListLatePayments lists the payments that arrived for cancelled orders,
+// newest first.
+func (h *OrderHandler) AdminListLatePayments(c *fiber.Ctx) error {
+	var filter domain.LatePaymentFilter
+	if err := c.QueryParser(&filter); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] AdminListLatePayments", "query", err)
+		status, response := response.FromError(fmt.Errorf("%w: %v", domain.ErrBadRequest, err))
+		return c.Status(status).JSON(response)
+	}
+	if err := h.validator.Struct(filter); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] AdminListLatePayments", "validation", err)
+		status, response := response.FromError(validation.Translate(err))
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.OrderUsecase.AdminListLatePayments(c.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] AdminListLatePayments", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
+
+func (h *OrderHandler) AdminGetOrderByID(c *fiber.Ctx) error {
+	idstr := c.Params("id")
+	id, err := strconv.ParseInt(idstr, 10, 64)
+	if err != nil || id <= 0 {
+		slog.ErrorContext(c.Context(), "[OrderHandler] AdminGetOrderByID", "params:"+idstr, err)
+		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
+	}
+
+	res, err := h.OrderUsecase.AdminGetOrderByID(c.Context(), id)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] AdminGetOrderByID", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return orderJSON(c, fiber.StatusOK, res)
+}
+
+func (h *OrderHandler) ForceCancelOrder(c *fiber.Ctx) error {
+	id, adminID, req, err := h.adminActionParams(c)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] ForceCancelOrder", "params", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.OrderUsecase.ForceCancelOrder(c.Context(), adminID, id, req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] ForceCancelOrder", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return orderJSON(c, fiber.StatusOK, res)
+}
+
+func (h *OrderHandler) ForceCompleteOrder(c *fiber.Ctx) error {
+	id, adminID, req, err := h.adminActionParams(c)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] ForceCompleteOrder", "params", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.OrderUsecase.ForceCompleteOrder(c.Context(), adminID, id, req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] ForceCompleteOrder", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return orderJSON(c, fiber.StatusOK, res)
+}
+
+// adminActionParams returns the order ID from the path, the acting admin from
+// the token and the validated request body.
+func (h *OrderHandler) adminActionParams(c *fiber.Ctx) (int64, int64, domain.AdminOrderActionRequest, error) {
+	var req domain.AdminOrderActionRequest
+
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, 0, req, fmt.Errorf("%w: invalid order ID", domain.ErrBadRequest)
+	}
+
+	adminID, err := ctxutil.GetUserIDCtx(c.Context())
+	if err != nil {
+		return 0, 0, req, fmt.Errorf("%w: %v", domain.ErrUnauthorized, err)
+	}
+
+	if err := bindBody(c, h.validator, &req); err != nil {
+		return 0, 0, req, err
+	}
+	return id, adminID, req, nil
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/app/handler/response"
+	"order-service/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditHandler struct {
+	AuditUsecase domain.AuditUsecase
+	validator    *validator.Validate
+}
+
+func NewAuditHandler(auditUsecase domain.AuditUsecase, validator *validator.Validate) *AuditHandler {
+	return &AuditHandler{
+		AuditUsecase: auditUsecase,
+		validator:    validator,
+	}
+}
+
+func (h *AuditHandler) SearchAuditLogs(c *fiber.Ctx) error {
+	var filter domain.AuditFilter
+	if err := c.QueryParser(&filter); err != nil {
+		slog.ErrorContext(c.Context(), "[AuditHandler] SearchAuditLogs", "query", err)
+		status, response := response.FromError(fmt.Errorf("%w: %v", domain.ErrBadRequest, err))
+		return c.Status(status).JSON(response)
+	}
+	if err := h.validator.Struct(filter); err != nil {
+		slog.ErrorContext(c.Context(), "[AuditHandler] SearchAuditLogs", "validation", err)
+		status, response := response.FromError(validation.Translate(err))
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.AuditUsecase.SearchAuditLogs(c.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[AuditHandler] SearchAuditLogs", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/config"
+	"order-service/pkg/ctxutil"
+	"order-service/pkg/validation"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// errPoisonMessage marks messages that can never be processed, they are
+// dead-lettered on the first attempt.
+var errPoisonMessage = errors.New("poison message")
+
+// EventConsumer maps messages of the payment and warehouse topics to order
+// usecase calls. It is the broker counterpart of the HTTP handlers.
+type EventConsumer struct {
+	OrderUsecase domain.OrderUsecase
+	InboxUsecase domain.InboxUsecase
+	subscriber   domain.EventSubscriber
+	validator    *validator.Validate
+	cfg          *config.Store
+}
+
+func NewEventConsumer(orderUsecase domain.OrderUsecase, inboxUsecase domain.InboxUsecase, subscriber domain.EventSubscriber,
+	validator *validator.Validate, cfg *config.Store) *EventConsumer {
+	return &EventConsumer{
+		OrderUsecase: orderUsecase,
+		InboxUsecase: inboxUsecase,
+		subscriber:   subscriber,
+		validator:    validator,
+		cfg:          cfg,
+	}
+}
+
+// Run subscribes to every topic and blocks until ctx is done.
+func (h *EventConsumer) Run(ctx context.Context) {
+	cfg := h.cfg.Get().Event
+	handlers := map[string]func(ctx context.Context, msg domain.Message) error{
+		cfg.PaymentSucceededTopic: h.handlePaymentSucceeded,
+		cfg.PaymentFailedTopic:    h.handlePaymentFailed,
+		cfg.StockReleasedTopic:    h.handleStockReleased,
+	}
+
+	var wg sync.WaitGroup
+	for topic, handle := range handlers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slog.InfoContext(ctx, "[EventConsumer] Subscribing", "topic", topic)
+			if err := h.subscriber.Subscribe(ctx, topic, h.deliver(handle)); err != nil {
+				slog.ErrorContext(ctx, "[EventConsumer] Subscribe", "topic", topic, "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver wraps handle with the retry policy: transient errors are returned
+// so the broker redelivers the message, poison messages and messages out of
+// attempts are dead-lettered and acknowledged.
+func (h *EventConsumer) deliver(handle func(ctx context.Context, msg domain.Message) error) domain.MessageHandler {
+	return func(ctx context.Context, delivery domain.Delivery) error {
+		ctx = ctxutil.WithRequestID(ctx, delivery.ID)
+
+		err := handle(ctx, delivery.Message)
+		if err == nil {
+			return nil
+		}
+
+		if !isPermanent(err) && delivery.Attempt < h.cfg.Get().Event.ConsumerMaxAttempts {
+			slog.WarnContext(ctx, "[EventConsumer] message failed, will be retried", "topic", delivery.Topic, "attempt", delivery.Attempt, "error", err)
+			return err
+		}
+		// a failing dead letter store keeps the message in the broker
+		return h.InboxUsecase.DeadLetter(ctx, delivery, err)
+	}
+}
+
+func isPermanent(err error) bool {
+	return errors.Is(err, errPoisonMessage) ||
+		errors.Is(err, domain.ErrValidation) ||
+		errors.Is(err, domain.ErrBadRequest) ||
+		errors.Is(err, domain.ErrNotFound) ||
+		errors.Is(err, domain.ErrConflict)
+}
+
+func (h *EventConsumer) handlePaymentSucceeded(ctx context.Context, msg domain.Message) error {
+	var payload domain.PaymentEventPayload
+	if err := h.decode(msg, &payload); err != nil {
+		return err
+	}
+	return h.OrderUsecase.HandlePaymentEvent(ctx, msg, domain.OrderStatusPaid, payload)
+}
+
+func (h *EventConsumer) handlePaymentFailed(ctx context.Context, msg domain.Message) error {
+	var payload domain.PaymentEventPayload
+	if err := h.decode(msg, &payload); err != nil {
+		return err
+	}
+	return h.OrderUsecase.HandlePaymentEvent(ctx, msg, domain.OrderStatusCancelled, payload)
+}
+
+func (h *EventConsumer) handleStockReleased(ctx context.Context, msg domain.Message) error {
+	var payload domain.StockReleasedPayload
+	if err := h.decode(msg, &payload); err != nil {
+		return err
+	}
+	return h.OrderUsecase.HandleStockReleased(ctx, msg, payload)
+}
+
+func (h *EventConsumer) decode(msg domain.Message, payload any) error {
+	if err := json.Unmarshal(msg.Payload, payload); err != nil {
+		return fmt.Errorf("%w: %v", errPoisonMessage, err)
+	}
+	if err := h.validator.Struct(payload); err != nil {
+		return fmt.Errorf("%w: %v", errPoisonMessage, validation.Translate(err))
+	}
+	return nil
+}
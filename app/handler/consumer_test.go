@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"order-service/app/domain"
+	"order-service/config"
+	"order-service/pkg/validation"
+	"testing"
+)
+
+type fakeInboxUsecase struct {
+	deadLetters []domain.Delivery
+}
+
+func (u *fakeInboxUsecase) DeadLetter(ctx context.Context, delivery domain.Delivery, cause error) error {
+	u.deadLetters = append(u.deadLetters, delivery)
+	return nil
+}
+
+func TestEventConsumerDeadLetters(t *testing.T) {
+	errTransient := errors.New("database unavailable")
+
+	tests := []struct {
+		name             string
+		payload          string
+		handleErr        error
+		wantAttempts     int
+		wantDeadLettered bool
+	}{
+		{name: "handled", payload: `{"order_id":1}`, wantAttempts: 1},
+		{name: "transient error until out of attempts", payload: `{"order_id":1}`, handleErr: errTransient, wantAttempts: 3, wantDeadLettered: true},
+		{name: "conflict is not retried", payload: `{"order_id":1}`, handleErr: fmt.Errorf("%w: order is paid", domain.ErrConflict), wantAttempts: 1, wantDeadLettered: true},
+		{name: "undecodable payload is poison", payload: `{"order_id":`, wantAttempts: 1, wantDeadLettered: true},
+		{name: "invalid payload is poison", payload: `{"order_id":0}`, wantAttempts: 1, wantDeadLettered: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfgStore := config.NewStore(&config.Config{Event: config.EventConfig{ConsumerMaxAttempts: 3}})
+			v, err := validation.New(cfgStore)
+			if err != nil {
+				t.Fatal(err)
+			}
+			inbox := &fakeInboxUsecase{}
+			consumer := NewEventConsumer(nil, inbox, nil, v, cfgStore)
+
+			handle := func(ctx context.Context, msg domain.Message) error {
+				var payload struct {
+					OrderID int64 `json:"order_id" validate:"required"`
+				}
+				if err := consumer.decode(msg, &payload); err != nil {
+					return err
+				}
+				return tt.handleErr
+			}
+			deliver := consumer.deliver(handle)
+
+			// redeliver like the broker does until the handler acknowledges
+			msg := domain.Message{ID: "m1", Topic: "payment-service.payment.succeeded.v1", Payload: []byte(tt.payload)}
+			attempts := 0
+			for attempts < 10 {
+				attempts++
+				if deliver(context.Background(), domain.Delivery{Message: msg, Attempt: attempts}) == nil {
+					break
+				}
+			}
+
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			if got := len(inbox.deadLetters) == 1; got != tt.wantDeadLettered {
+				t.Errorf("dead letters = %v, want dead-lettered %v", inbox.deadLetters, tt.wantDeadLettered)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/app/handler/response"
+	"order-service/pkg/ctxutil"
+	"order-service/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CouponHandler struct {
+	CouponUsecase domain.CouponUsecase
+	validator     *validator.Validate
+}
+
+func NewCouponHandler(couponUsecase domain.CouponUsecase, validator *validator.Validate) *CouponHandler {
+	return &CouponHandler{
+		CouponUsecase: couponUsecase,
+		validator:     validator,
+	}
+}
+
+func (h *CouponHandler) CreateCoupon(c *fiber.Ctx) error {
+	var req domain.CouponRequest
+	if err := bindBody(c, h.validator, &req); err != nil {
+		slog.ErrorContext(c.Context(), "[CouponHandler] CreateCoupon", "body", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	adminID, err := ctxutil.GetUserIDCtx(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[CouponHandler] CreateCoupon", "getUserIDCtx", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
+	}
+
+	res, err := h.CouponUsecase.CreateCoupon(c.Context(), adminID, req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[CouponHandler] CreateCoupon", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusCreated).JSON(response.Success(res))
+}
+
+func (h *CouponHandler) ListCoupons(c *fiber.Ctx) error {
+	var filter domain.CouponFilter
+	if err := c.QueryParser(&filter); err != nil {
+		slog.ErrorContext(c.Context(), "[CouponHandler] ListCoupons", "query", err)
+		status, response := response.FromError(fmt.Errorf("%w: %v", domain.ErrBadRequest, err))
+		return c.Status(status).JSON(response)
+	}
+	if err := h.validator.Struct(filter); err != nil {
+		slog.ErrorContext(c.Context(), "[CouponHandler] ListCoupons", "validation", err)
+		status, response := response.FromError(validation.Translate(err))
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.CouponUsecase.ListCoupons(c.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[CouponHandler] ListCoupons", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
+
+func (h *CouponHandler) GetCouponByID(c *fiber.Ctx) error {
+	id, err := idParam(c)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[CouponHandler] GetCouponByID", "params", err)
+		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
+	}
+
+	res, err := h.CouponUsecase.GetCouponByID(c.Context(), id)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[CouponHandler] GetCouponByID", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
+
+func (h *CouponHandler) UpdateCoupon(c *fiber.Ctx) error {
+	id, err := idParam(c)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[CouponHandler] UpdateCoupon", "params", err)
+		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
+	}
+
+	var req domain.CouponRequest
+	if err := bindBody(c, h.validator, &req); err != nil {
+		slog.ErrorContext(c.Context(), "[CouponHandler] UpdateCoupon", "body", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	adminID, err := ctxutil.GetUserIDCtx(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[CouponHandler] UpdateCoupon", "getUserIDCtx", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
+	}
+
+	res, err := h.CouponUsecase.UpdateCoupon(c.Context(), adminID, id, req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[CouponHandler] UpdateCoupon", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"order-service/app/handler/docs"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func OpenAPISpec(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSONCharsetUTF8)
+	return c.Send(docs.OpenAPI)
+}
+
+func APIDocs(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(docs.IndexHTML)
+}
@@ -0,0 +1,11 @@
+// Package docs embeds the OpenAPI document of the HTTP API and the page
+// rendering it.
+package docs
+
+import _ "embed"
+
+//go:embed openapi.json
+var OpenAPI []byte
+
+//go:embed index.html
+var IndexHTML []byte
@@ -4,6 +4,7 @@ import (
 	"log/slog"
 	"order-service/app/domain"
 	"order-service/app/handler/response"
+	"order-service/pkg"
 	"order-service/pkg/ctxutil"
 	"strconv"
 
@@ -25,14 +26,10 @@ func NewOrderHandler(orderUsecase domain.OrderUsecase, validator *validator.Vali
 
 func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 	var order domain.OrderCreateRequest
-	if err := c.BodyParser(&order); err != nil {
+	if err := bindBody(c, h.validator, &order); err != nil {
 		slog.ErrorContext(c.Context(), "[OrderHandler] CreateOrder", "body", err)
-		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
-	}
-
-	if err := h.validator.Struct(order); err != nil {
-		slog.ErrorContext(c.Context(), "[OrderHandler] CreateOrder", "validation", err)
-		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
 	}
 
 	userID, err := ctxutil.GetUserIDCtx(c.Context())
@@ -48,7 +45,31 @@ func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
 		return c.Status(status).JSON(response)
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(response.Success(res))
+	return orderJSON(c, fiber.StatusCreated, res)
+}
+
+func (h *OrderHandler) QuoteOrder(c *fiber.Ctx) error {
+	var order domain.OrderCreateRequest
+	if err := bindBody(c, h.validator, &order); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] QuoteOrder", "body", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	userID, err := ctxutil.GetUserIDCtx(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] QuoteOrder", "getUserIDCtx", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
+	}
+
+	res, err := h.OrderUsecase.QuoteOrder(c.Context(), userID, order)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] QuoteOrder", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
 }
 
 func (h *OrderHandler) GetListByUserID(c *fiber.Ctx) error {
@@ -91,26 +112,119 @@ func (h *OrderHandler) GetOrderByID(c *fiber.Ctx) error {
 		status, response := response.FromError(err)
 		return c.Status(status).JSON(response)
 	}
-	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+	return orderJSON(c, fiber.StatusOK, res)
 }
 
 func (h *OrderHandler) UpdateStatusOrder(c *fiber.Ctx) error {
 	var req domain.OrderUpdateStatusRequest
-	if err := c.BodyParser(&req); err != nil {
+	if err := bindBody(c, h.validator, &req); err != nil {
 		slog.ErrorContext(c.Context(), "[OrderHandler] UpdateStatusOrder", "body", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	if err := h.OrderUsecase.UpdateStatusOrder(c.Context(), req); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] UpdateStatusOrder", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success[any](nil))
+}
+
+// orderJSON writes a single order with its version as the ETag, clients send
+// it back in If-Match to make sure they change what they have seen.
+func orderJSON(c *fiber.Ctx, status int, order domain.Order) error {
+	c.Set(fiber.HeaderETag, pkg.FormatETag(order.Version))
+	return c.Status(status).JSON(response.Success(order))
+}
+
+func (h *OrderHandler) UpdateOrder(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		slog.ErrorContext(c.Context(), "[OrderHandler] UpdateOrder", "params:"+c.Params("id"), err)
 		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
 	}
 
-	if err := h.validator.Struct(req); err != nil {
-		slog.ErrorContext(c.Context(), "[OrderHandler] UpdateStatusOrder", "validation", err)
+	var req domain.OrderUpdateRequest
+	if err := bindBody(c, h.validator, &req); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] UpdateOrder", "body", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	userID, err := ctxutil.GetUserIDCtx(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] UpdateOrder", "getUserIDCtx", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
+	}
+
+	res, err := h.OrderUsecase.UpdateOrder(c.Context(), userID, id, req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] UpdateOrder", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	return orderJSON(c, fiber.StatusOK, res)
+}
+
+func (h *OrderHandler) ExtendOrderExpiry(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		slog.ErrorContext(c.Context(), "[OrderHandler] ExtendOrderExpiry", "params:"+c.Params("id"), err)
 		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
 	}
 
-	if err := h.OrderUsecase.UpdateStatusOrder(c.Context(), req); err != nil {
-		slog.ErrorContext(c.Context(), "[OrderHandler] UpdateStatusOrder", "usecase", err)
+	var req domain.OrderExtendRequest
+	if err := bindBody(c, h.validator, &req); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] ExtendOrderExpiry", "body", err)
 		status, response := response.FromError(err)
 		return c.Status(status).JSON(response)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(response.Success[any](nil))
+	userID, err := ctxutil.GetUserIDCtx(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] ExtendOrderExpiry", "getUserIDCtx", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
+	}
+
+	res, err := h.OrderUsecase.ExtendOrderExpiry(c.Context(), userID, id, req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] ExtendOrderExpiry", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	return orderJSON(c, fiber.StatusOK, res)
+}
+
+func (h *OrderHandler) UpdateShipping(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		slog.ErrorContext(c.Context(), "[OrderHandler] UpdateShipping", "params:"+c.Params("id"), err)
+		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
+	}
+
+	var req domain.OrderShipping
+	if err := bindBody(c, h.validator, &req); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] UpdateShipping", "body", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	userID, err := ctxutil.GetUserIDCtx(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] UpdateShipping", "getUserIDCtx", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
+	}
+
+	res, err := h.OrderUsecase.UpdateShipping(c.Context(), userID, id, req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] UpdateShipping", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	return orderJSON(c, fiber.StatusOK, res)
 }
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/app/handler/response"
+	"order-service/pkg/ctxutil"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *OrderHandler) RequestShopRefund(c *fiber.Ctx) error {
+	id, shopID, sellerID, err := shopActionParams(c)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] RequestShopRefund", "params", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	var req domain.RefundCreateRequest
+	if err := bindBody(c, h.validator, &req); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] RequestShopRefund", "body", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.OrderUsecase.RequestShopRefund(c.Context(), shopID, sellerID, id, req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] RequestShopRefund", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusCreated).JSON(response.Success(res))
+}
+
+func (h *OrderHandler) GetShopOrderRefunds(c *fiber.Ctx) error {
+	id, shopID, _, err := shopActionParams(c)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] GetShopOrderRefunds", "params", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.OrderUsecase.GetShopOrderRefunds(c.Context(), shopID, id)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] GetShopOrderRefunds", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
+
+func (h *OrderHandler) RequestAdminRefund(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		slog.ErrorContext(c.Context(), "[OrderHandler] RequestAdminRefund", "params", err)
+		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
+	}
+
+	adminID, err := ctxutil.GetUserIDCtx(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] RequestAdminRefund", "getUserIDCtx", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
+	}
+
+	var req domain.RefundCreateRequest
+	if err := bindBody(c, h.validator, &req); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] RequestAdminRefund", "body", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.OrderUsecase.RequestAdminRefund(c.Context(), adminID, id, req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] RequestAdminRefund", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusCreated).JSON(response.Success(res))
+}
+
+func (h *OrderHandler) AdminGetOrderRefunds(c *fiber.Ctx) error {
+	idstr := c.Params("id")
+	id, err := strconv.ParseInt(idstr, 10, 64)
+	if err != nil || id <= 0 {
+		slog.ErrorContext(c.Context(), "[OrderHandler] AdminGetOrderRefunds", "params:"+idstr, err)
+		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
+	}
+
+	res, err := h.OrderUsecase.AdminGetOrderRefunds(c.Context(), id)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] AdminGetOrderRefunds", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
+
+// ConfirmRefund is called by the payment provider once a refund was paid out
+// or has failed.
+func (h *OrderHandler) ConfirmRefund(c *fiber.Ctx) error {
+	var req domain.RefundConfirmRequest
+	if err := bindBody(c, h.validator, &req); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] ConfirmRefund", "body", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.OrderUsecase.ConfirmRefund(c.Context(), req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] ConfirmRefund", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"fmt"
+	"order-service/app/domain"
+	"order-service/pkg/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// bindBody parses the request body into req and validates it. The returned
+// error wraps a domain error so it can be passed to response.FromError.
+func bindBody(c *fiber.Ctx, v *validator.Validate, req any) error {
+	if err := c.BodyParser(req); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrBadRequest, err)
+	}
+
+	if err := v.Struct(req); err != nil {
+		return validation.Translate(err)
+	}
+
+	return nil
+}
@@ -11,6 +11,7 @@ type Response[T any] struct {
 	Success bool   `json:"success"`
 	Data    T      `json:"data,omitempty"`
 	Error   string `json:"error,omitempty"`
+	Code    string `json:"code,omitempty"`
 }
 
 func Success[T any](data T) *Response[T] {
@@ -21,10 +22,15 @@ func Success[T any](data T) *Response[T] {
 }
 
 func Error(err error) *Response[any] {
-	return &Response[any]{
+	res := &Response[any]{
 		Success: false,
 		Error:   err.Error(),
 	}
+	var codeErr *domain.CodeError
+	if errors.As(err, &codeErr) {
+		res.Code = codeErr.Code
+	}
+	return res
 }
 
 func FromError(err error) (int, *Response[any]) {
@@ -35,6 +41,14 @@ func FromError(err error) (int, *Response[any]) {
 		return fiber.StatusBadRequest, Error(err)
 	case errors.Is(err, domain.ErrUnauthorized):
 		return fiber.StatusUnauthorized, Error(err)
+	case errors.Is(err, domain.ErrForbidden):
+		return fiber.StatusForbidden, Error(err)
+	case errors.Is(err, domain.ErrConflict):
+		return fiber.StatusConflict, Error(err)
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		return fiber.StatusPreconditionFailed, Error(err)
+	case errors.Is(err, domain.ErrTooManyRequest):
+		return fiber.StatusTooManyRequests, Error(err)
 	case errors.Is(err, domain.ErrNotFound):
 		return fiber.StatusNotFound, Error(err)
 	case errors.Is(err, domain.ErrBadRequest):
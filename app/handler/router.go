@@ -1,21 +1,72 @@
 package handler
 
 import (
+	"order-service/app/domain"
 	"order-service/app/middleware"
 	"order-service/config"
+	"order-service/pkg"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupRouter(app *fiber.App, orderHandler *OrderHandler, cfg *config.Config) {
+func SetupRouter(app *fiber.App, orderHandler *OrderHandler, auditHandler *AuditHandler, webhookHandler *WebhookHandler, couponHandler *CouponHandler, cfgStore *config.Store, keySet *pkg.JWKS, rateLimiter *middleware.RateLimiter) {
+	// API reference, kept in sync with the routes below by TestOpenAPICoversRoutes
+	app.Get("/openapi.json", OpenAPISpec)
+	app.Get("/docs", APIDocs)
+
 	// Setup routes
-	apiGroup := app.Group("/order-service").Use(middleware.Auth(cfg.Jwt.SecretKey))
-	callback := app.Group("/callback/order-service").Use(middleware.AuthPayment(cfg))
+	apiGroup := app.Group("/order-service").Use(middleware.Auth(cfgStore, keySet), rateLimiter.Handler())
+	callback := app.Group("/callback/order-service").Use(middleware.AuthPayment(cfgStore), rateLimiter.Handler())
 
 	apiGroup.Get("/orders/:id", orderHandler.GetOrderByID)
 	apiGroup.Get("/orders", orderHandler.GetListByUserID)
 	apiGroup.Post("/orders", orderHandler.CreateOrder)
+	apiGroup.Post("/orders/quote", orderHandler.QuoteOrder)
+	apiGroup.Patch("/orders/:id", orderHandler.UpdateOrder)
+	apiGroup.Put("/orders/:id/shipping", orderHandler.UpdateShipping)
+	apiGroup.Post("/orders/:id/extend-expiry", orderHandler.ExtendOrderExpiry)
+
+	// seller endpoints, scoped to the shop in the token
+	shopGroup := apiGroup.Group("/shop", middleware.ShopOnly())
+	shopGroup.Get("/orders/:id", orderHandler.GetShopOrderByID)
+	shopGroup.Get("/orders", orderHandler.GetListByShopID)
+	shopGroup.Post("/orders/:id/accept", orderHandler.AcceptOrder)
+	shopGroup.Post("/orders/:id/reject", orderHandler.RejectOrder)
+	shopGroup.Post("/orders/:id/ship", orderHandler.ShipOrder)
+	shopGroup.Post("/orders/:id/refunds", orderHandler.RequestShopRefund)
+	shopGroup.Get("/orders/:id/refunds", orderHandler.GetShopOrderRefunds)
+
+	// admin endpoints, every override is recorded in the order history and the audit log
+	adminGroup := app.Group("/admin/order-service").Use(middleware.Auth(cfgStore, keySet), rateLimiter.Handler())
+	adminGroup.Get("/orders/:id", middleware.RequirePermission(domain.PermissionOrderRead), orderHandler.AdminGetOrderByID)
+	adminGroup.Get("/orders", middleware.RequirePermission(domain.PermissionOrderRead), orderHandler.AdminSearchOrders)
+	adminGroup.Post("/orders/:id/cancel", middleware.RequirePermission(domain.PermissionOrderWrite), orderHandler.ForceCancelOrder)
+	adminGroup.Post("/orders/:id/complete", middleware.RequirePermission(domain.PermissionOrderWrite), orderHandler.ForceCompleteOrder)
+	adminGroup.Post("/orders/:id/refunds", middleware.RequirePermission(domain.PermissionOrderWrite), orderHandler.RequestAdminRefund)
+	adminGroup.Get("/orders/:id/refunds", middleware.RequirePermission(domain.PermissionOrderRead), orderHandler.AdminGetOrderRefunds)
+	adminGroup.Get("/late-payments", middleware.RequirePermission(domain.PermissionOrderRead), orderHandler.AdminListLatePayments)
+	adminGroup.Get("/audit-logs", middleware.RequirePermission(domain.PermissionAuditRead), auditHandler.SearchAuditLogs)
+
+	// outbound webhook subscriptions and their delivery log
+	// the permission is passed per route, a group without its own prefix would
+	// apply it to every admin route registered after it
+	webhookManage := middleware.RequirePermission(domain.PermissionWebhookManage)
+	adminGroup.Post("/webhooks", webhookManage, webhookHandler.CreateSubscription)
+	adminGroup.Get("/webhooks", webhookManage, webhookHandler.ListSubscriptions)
+	adminGroup.Get("/webhooks/:id", webhookManage, webhookHandler.GetSubscriptionByID)
+	adminGroup.Put("/webhooks/:id", webhookManage, webhookHandler.UpdateSubscription)
+	adminGroup.Delete("/webhooks/:id", webhookManage, webhookHandler.DeleteSubscription)
+	adminGroup.Get("/webhook-deliveries", webhookManage, webhookHandler.ListDeliveries)
+	adminGroup.Post("/webhook-deliveries/:id/replay", webhookManage, webhookHandler.ReplayDelivery)
+
+	// promo codes applied when orders are created
+	couponManage := middleware.RequirePermission(domain.PermissionCouponManage)
+	adminGroup.Post("/coupons", couponManage, couponHandler.CreateCoupon)
+	adminGroup.Get("/coupons", couponManage, couponHandler.ListCoupons)
+	adminGroup.Get("/coupons/:id", couponManage, couponHandler.GetCouponByID)
+	adminGroup.Put("/coupons/:id", couponManage, couponHandler.UpdateCoupon)
 
 	// callback payment update order status
 	callback.Post("/orders", orderHandler.UpdateStatusOrder)
+	callback.Post("/refunds", orderHandler.ConfirmRefund)
 }
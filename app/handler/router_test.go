@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"order-service/app/handler/docs"
+	"order-service/app/middleware"
+	"order-service/app/repository/memory"
+	"order-service/config"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var pathParam = regexp.MustCompile(`:(\w+)`)
+
+// TestOpenAPICoversRoutes fails when a route registered by SetupRouter is
+// missing from the OpenAPI document, or the document lists a route that does
+// not exist.
+func TestOpenAPICoversRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(docs.OpenAPI, &spec); err != nil {
+		t.Fatalf("invalid openapi.json: %v", err)
+	}
+
+	documented := map[string]bool{}
+	for path, ops := range spec.Paths {
+		for method := range ops {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	cfgStore := config.NewStore(&config.Config{})
+	rateLimiter, err := middleware.NewRateLimiter(memory.NewRateLimitStore(), cfgStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	SetupRouter(app, &OrderHandler{}, &AuditHandler{}, &WebhookHandler{}, &CouponHandler{}, cfgStore, nil, rateLimiter)
+
+	registered := map[string]bool{}
+	for _, route := range app.GetRoutes(true) {
+		// fiber adds a HEAD route for every GET route
+		if route.Method == http.MethodHead {
+			continue
+		}
+		key := route.Method + " " + pathParam.ReplaceAllString(route.Path, "{$1}")
+		registered[key] = true
+		if !documented[key] {
+			t.Errorf("route %s is not documented in openapi.json", key)
+		}
+	}
+
+	for key := range documented {
+		if !registered[key] {
+			t.Errorf("openapi.json documents %s, which is not registered", key)
+		}
+	}
+}
+
+// TestAdminPermissions checks that every admin route asks for its own
+// permission only. The requests use invalid IDs, so a granted request stops
+// in the handler with 400 before any usecase is called.
+func TestAdminPermissions(t *testing.T) {
+	cfgStore := config.NewStore(&config.Config{Jwt: config.JwtConfig{Mode: "hmac", SecretKey: "secret"}})
+	rateLimiter, err := middleware.NewRateLimiter(memory.NewRateLimitStore(), cfgStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := fiber.New()
+	SetupRouter(app, &OrderHandler{}, &AuditHandler{}, &WebhookHandler{}, &CouponHandler{}, cfgStore, nil, rateLimiter)
+
+	tokenWith := func(perm string) string {
+		claims := jwt.MapClaims{"uid": float64(1), "perms": []string{perm}, "exp": time.Now().Add(time.Hour).Unix()}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	paths := map[string]string{
+		"orders":   "/admin/order-service/orders/x",
+		"webhooks": "/admin/order-service/webhooks/x",
+		"coupons":  "/admin/order-service/coupons/x",
+		"unknown":  "/admin/order-service/unknown",
+	}
+	tests := []struct {
+		perm string
+		want map[string]int
+	}{
+		{perm: "orders:read", want: map[string]int{"orders": http.StatusBadRequest, "webhooks": http.StatusForbidden, "coupons": http.StatusForbidden, "unknown": http.StatusNotFound}},
+		{perm: "webhooks:manage", want: map[string]int{"orders": http.StatusForbidden, "webhooks": http.StatusBadRequest, "coupons": http.StatusForbidden, "unknown": http.StatusNotFound}},
+		{perm: "coupons:manage", want: map[string]int{"orders": http.StatusForbidden, "webhooks": http.StatusForbidden, "coupons": http.StatusBadRequest, "unknown": http.StatusNotFound}},
+	}
+	for _, tt := range tests {
+		token := tokenWith(tt.perm)
+		for name, want := range tt.want {
+			t.Run(tt.perm+" "+name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, paths[name], nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				resp, err := app.Test(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.StatusCode != want {
+					t.Errorf("GET %s = %d, want %d", paths[name], resp.StatusCode, want)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/app/handler/response"
+	"order-service/pkg/ctxutil"
+	"order-service/pkg/validation"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func (h *OrderHandler) GetListByShopID(c *fiber.Ctx) error {
+	shopID, err := ctxutil.GetShopIDCtx(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] GetListByShopID", "getShopIDCtx", err)
+		return c.Status(fiber.StatusForbidden).JSON(response.Error(domain.ErrForbidden))
+	}
+
+	var filter domain.OrderFilter
+	if err := c.QueryParser(&filter); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] GetListByShopID", "query", err)
+		status, response := response.FromError(fmt.Errorf("%w: %v", domain.ErrBadRequest, err))
+		return c.Status(status).JSON(response)
+	}
+	if err := h.validator.Struct(filter); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] GetListByShopID", "validation", err)
+		status, response := response.FromError(validation.Translate(err))
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.OrderUsecase.GetListByShopID(c.Context(), shopID, filter)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] GetListByShopID", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
+
+func (h *OrderHandler) GetShopOrderByID(c *fiber.Ctx) error {
+	idstr := c.Params("id")
+	id, err := strconv.ParseInt(idstr, 10, 64)
+	if err != nil || id <= 0 {
+		slog.ErrorContext(c.Context(), "[OrderHandler] GetShopOrderByID", "params:"+idstr, err)
+		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
+	}
+
+	shopID, err := ctxutil.GetShopIDCtx(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] GetShopOrderByID", "getShopIDCtx", err)
+		return c.Status(fiber.StatusForbidden).JSON(response.Error(domain.ErrForbidden))
+	}
+
+	res, err := h.OrderUsecase.GetShopOrderByID(c.Context(), shopID, id)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] GetShopOrderByID", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return orderJSON(c, fiber.StatusOK, res)
+}
+
+func (h *OrderHandler) AcceptOrder(c *fiber.Ctx) error {
+	id, shopID, sellerID, err := shopActionParams(c)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] AcceptOrder", "params", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.OrderUsecase.AcceptOrder(c.Context(), shopID, sellerID, id)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] AcceptOrder", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return orderJSON(c, fiber.StatusOK, res)
+}
+
+func (h *OrderHandler) RejectOrder(c *fiber.Ctx) error {
+	id, shopID, sellerID, err := shopActionParams(c)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] RejectOrder", "params", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	var req domain.OrderRejectRequest
+	if err := bindBody(c, h.validator, &req); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] RejectOrder", "body", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.OrderUsecase.RejectOrder(c.Context(), shopID, sellerID, id, req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] RejectOrder", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return orderJSON(c, fiber.StatusOK, res)
+}
+
+func (h *OrderHandler) ShipOrder(c *fiber.Ctx) error {
+	id, shopID, sellerID, err := shopActionParams(c)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] ShipOrder", "params", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	var req domain.OrderShipRequest
+	if err := bindBody(c, h.validator, &req); err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] ShipOrder", "body", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.OrderUsecase.ShipOrder(c.Context(), shopID, sellerID, id, req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[OrderHandler] ShipOrder", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return orderJSON(c, fiber.StatusOK, res)
+}
+
+// shopActionParams returns the order ID from the path, and the shop and the
+// acting seller from the token.
+func shopActionParams(c *fiber.Ctx) (id, shopID, sellerID int64, err error) {
+	id, err = strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, 0, 0, fmt.Errorf("%w: invalid order ID", domain.ErrBadRequest)
+	}
+
+	shopID, err = ctxutil.GetShopIDCtx(c.Context())
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: %v", domain.ErrForbidden, err)
+	}
+
+	sellerID, err = ctxutil.GetUserIDCtx(c.Context())
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%w: %v", domain.ErrUnauthorized, err)
+	}
+	return id, shopID, sellerID, nil
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/app/handler/response"
+	"order-service/pkg/ctxutil"
+	"order-service/pkg/validation"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookHandler struct {
+	WebhookUsecase domain.WebhookUsecase
+	validator      *validator.Validate
+}
+
+func NewWebhookHandler(webhookUsecase domain.WebhookUsecase, validator *validator.Validate) *WebhookHandler {
+	return &WebhookHandler{
+		WebhookUsecase: webhookUsecase,
+		validator:      validator,
+	}
+}
+
+func (h *WebhookHandler) CreateSubscription(c *fiber.Ctx) error {
+	var req domain.WebhookSubscriptionRequest
+	if err := bindBody(c, h.validator, &req); err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] CreateSubscription", "body", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	adminID, err := ctxutil.GetUserIDCtx(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] CreateSubscription", "getUserIDCtx", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
+	}
+
+	res, err := h.WebhookUsecase.CreateSubscription(c.Context(), adminID, req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] CreateSubscription", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusCreated).JSON(response.Success(res))
+}
+
+func (h *WebhookHandler) ListSubscriptions(c *fiber.Ctx) error {
+	res, err := h.WebhookUsecase.ListSubscriptions(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] ListSubscriptions", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
+
+func (h *WebhookHandler) GetSubscriptionByID(c *fiber.Ctx) error {
+	id, err := idParam(c)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] GetSubscriptionByID", "params", err)
+		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
+	}
+
+	res, err := h.WebhookUsecase.GetSubscriptionByID(c.Context(), id)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] GetSubscriptionByID", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
+
+func (h *WebhookHandler) UpdateSubscription(c *fiber.Ctx) error {
+	id, err := idParam(c)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] UpdateSubscription", "params", err)
+		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
+	}
+
+	var req domain.WebhookSubscriptionRequest
+	if err := bindBody(c, h.validator, &req); err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] UpdateSubscription", "body", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+
+	adminID, err := ctxutil.GetUserIDCtx(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] UpdateSubscription", "getUserIDCtx", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
+	}
+
+	res, err := h.WebhookUsecase.UpdateSubscription(c.Context(), adminID, id, req)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] UpdateSubscription", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
+
+func (h *WebhookHandler) DeleteSubscription(c *fiber.Ctx) error {
+	id, err := idParam(c)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] DeleteSubscription", "params", err)
+		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
+	}
+
+	adminID, err := ctxutil.GetUserIDCtx(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] DeleteSubscription", "getUserIDCtx", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
+	}
+
+	if err := h.WebhookUsecase.DeleteSubscription(c.Context(), adminID, id); err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] DeleteSubscription", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success[any](nil))
+}
+
+func (h *WebhookHandler) ListDeliveries(c *fiber.Ctx) error {
+	var filter domain.WebhookDeliveryFilter
+	if err := c.QueryParser(&filter); err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] ListDeliveries", "query", err)
+		status, response := response.FromError(fmt.Errorf("%w: %v", domain.ErrBadRequest, err))
+		return c.Status(status).JSON(response)
+	}
+	if err := h.validator.Struct(filter); err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] ListDeliveries", "validation", err)
+		status, response := response.FromError(validation.Translate(err))
+		return c.Status(status).JSON(response)
+	}
+
+	res, err := h.WebhookUsecase.ListDeliveries(c.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] ListDeliveries", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusOK).JSON(response.Success(res))
+}
+
+func (h *WebhookHandler) ReplayDelivery(c *fiber.Ctx) error {
+	id, err := idParam(c)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] ReplayDelivery", "params", err)
+		return c.Status(fiber.StatusBadRequest).JSON(response.Error(domain.ErrBadRequest))
+	}
+
+	adminID, err := ctxutil.GetUserIDCtx(c.Context())
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] ReplayDelivery", "getUserIDCtx", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
+	}
+
+	if err := h.WebhookUsecase.ReplayDelivery(c.Context(), adminID, id); err != nil {
+		slog.ErrorContext(c.Context(), "[WebhookHandler] ReplayDelivery", "usecase", err)
+		status, response := response.FromError(err)
+		return c.Status(status).JSON(response)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(response.Success[any](nil))
+}
+
+func idParam(c *fiber.Ctx) (int64, error) {
+	idstr := c.Params("id")
+	id, err := strconv.ParseInt(idstr, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: invalid id %q", domain.ErrBadRequest, idstr)
+	}
+	return id, nil
+}
@@ -7,6 +7,7 @@ import (
 	"order-service/config"
 	"order-service/pkg"
 	"order-service/pkg/ctxutil"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -17,7 +18,7 @@ const (
 	AuthPaymentHeaderKey AuthHeader = "X-Payment-Auth"
 )
 
-func AuthPayment(cfg *config.Config) fiber.Handler {
+func AuthPayment(cfgStore *config.Store) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get the auth header from the request
 		authHeader := c.Get(string(AuthPaymentHeaderKey))
@@ -25,7 +26,7 @@ func AuthPayment(cfg *config.Config) fiber.Handler {
 			return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
 		}
 		// Check if the auth header is valid (you can implement your own logic here)
-		if authHeader != cfg.AuthPaymentHeader {
+		if authHeader != cfgStore.Get().AuthPaymentHeader {
 			return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
 		}
 
@@ -33,7 +34,9 @@ func AuthPayment(cfg *config.Config) fiber.Handler {
 	}
 }
 
-func Auth(secretKey string) fiber.Handler {
+// Auth verifies the bearer token according to the JWT mode. keySet may be
+// nil when the mode is hmac.
+func Auth(cfgStore *config.Store, keySet *pkg.JWKS) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 
 		token, err := pkg.GetTokenFromHeaders(c.Get("Authorization"))
@@ -42,7 +45,7 @@ func Auth(secretKey string) fiber.Handler {
 			return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
 		}
 
-		claims, err := pkg.ParseJwtToken(token, secretKey)
+		claims, err := pkg.ParseJwtTokenWithOptions(c.Context(), token, verifyOptions(cfgStore.Get().Jwt, keySet))
 		if err != nil {
 			slog.ErrorContext(c.Context(), "[middleware] Auth", "ParseJwtToken", err)
 			return c.Status(fiber.StatusUnauthorized).JSON(response.Error(domain.ErrUnauthorized))
@@ -54,7 +57,38 @@ func Auth(secretKey string) fiber.Handler {
 		}
 
 		c.Locals(ctxutil.UserIDKey, claims.UID)
+		if claims.SID != nil && *claims.SID > 0 {
+			c.Locals(ctxutil.ShopIDKey, *claims.SID)
+		}
+		c.Locals(ctxutil.RolesKey, claims.Roles)
+		c.Locals(ctxutil.PermsKey, claims.Permissions)
+
+		return c.Next()
+	}
+}
 
+// ShopOnly must run after Auth, it rejects tokens without a shop claim.
+func ShopOnly() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, err := ctxutil.GetShopIDCtx(c.Context()); err != nil {
+			slog.ErrorContext(c.Context(), "[middleware] ShopOnly", "getShopIDCtx", err)
+			return c.Status(fiber.StatusForbidden).JSON(response.Error(domain.ErrForbidden))
+		}
 		return c.Next()
 	}
 }
+
+func verifyOptions(cfg config.JwtConfig, keySet *pkg.JWKS) pkg.VerifyOptions {
+	opts := pkg.VerifyOptions{
+		Issuer:   cfg.Issuer,
+		Audience: cfg.Audience,
+		Leeway:   time.Duration(cfg.LeewaySeconds) * time.Second,
+	}
+	if cfg.Mode != "jwks" {
+		opts.HMACSecret = cfg.SecretKey
+	}
+	if cfg.Mode != "hmac" {
+		opts.KeySet = keySet
+	}
+	return opts
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"order-service/config"
+	"order-service/pkg"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func signHS256(t *testing.T, secret string) string {
+	t.Helper()
+	claims := jwt.MapClaims{"uid": float64(7), "exp": time.Now().Add(time.Hour).Unix()}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAuthModes(t *testing.T) {
+	tests := []struct {
+		name   string
+		jwt    config.JwtConfig
+		keySet *pkg.JWKS
+		token  string
+		want   int
+	}{
+		{name: "hmac", jwt: config.JwtConfig{Mode: "hmac", SecretKey: "secret"}, token: signHS256(t, "secret"), want: http.StatusOK},
+		{name: "hmac wrong secret", jwt: config.JwtConfig{Mode: "hmac", SecretKey: "secret"}, token: signHS256(t, "other"), want: http.StatusUnauthorized},
+		{name: "hmac empty secret", jwt: config.JwtConfig{Mode: "hmac"}, token: signHS256(t, ""), want: http.StatusUnauthorized},
+		{name: "jwks without key set", jwt: config.JwtConfig{Mode: "jwks", SecretKey: "secret"}, token: signHS256(t, "secret"), want: http.StatusUnauthorized},
+		{name: "jwks ignores the secret", jwt: config.JwtConfig{Mode: "jwks", SecretKey: "secret"}, keySet: pkg.NewJWKS("missing.json", time.Hour), token: signHS256(t, "secret"), want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfgStore := config.NewStore(&config.Config{Jwt: tt.jwt})
+			app := fiber.New()
+			app.Get("/", Auth(cfgStore, tt.keySet), func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/app/handler/response"
+	"order-service/pkg"
+	"order-service/pkg/ctxutil"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// IfMatchMiddleware stores the order version sent in If-Match on mutating
+// requests. Usecases compare it with the locked order and fail with
+// domain.ErrPreconditionFailed when another write came first.
+func IfMatchMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		header := c.Get(fiber.HeaderIfMatch)
+		if header == "" || c.Method() == fiber.MethodGet || c.Method() == fiber.MethodHead {
+			return c.Next()
+		}
+
+		version, wildcard, err := pkg.ParseIfMatch(header)
+		if err != nil {
+			slog.ErrorContext(c.Context(), "[middleware] IfMatchMiddleware", "ParseIfMatch", err)
+			return c.Status(fiber.StatusBadRequest).JSON(response.Error(fmt.Errorf("%w: %v", domain.ErrBadRequest, err)))
+		}
+		if !wildcard {
+			c.Locals(ctxutil.ExpectedVersionKey, version)
+		}
+		return c.Next()
+	}
+}
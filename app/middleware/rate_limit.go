@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"order-service/app/domain"
+	"order-service/app/handler/response"
+	"order-service/config"
+	"order-service/pkg/ctxutil"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimiter enforces the per route limits of RATE_LIMIT_RULES, keyed by the
+// user in the token and by client IP. Behind a load balancer the client IP
+// needs PROXY_HEADER and TRUSTED_PROXIES. Rules are swapped on config reload.
+type RateLimiter struct {
+	store   domain.RateLimitStore
+	enabled atomic.Bool
+	rules   atomic.Pointer[[]config.RateLimitRule]
+}
+
+func NewRateLimiter(store domain.RateLimitStore, cfgStore *config.Store) (*RateLimiter, error) {
+	l := &RateLimiter{store: store}
+	if err := l.apply(cfgStore.Get().RateLimit); err != nil {
+		return nil, err
+	}
+
+	cfgStore.Subscribe("rate_limiter", func(c *config.Config) any {
+		return c.RateLimit
+	}, func(c *config.Config) {
+		if err := l.apply(c.RateLimit); err != nil {
+			slog.Error("[RateLimiter] reload rules", "error", err)
+		}
+	})
+	return l, nil
+}
+
+func (l *RateLimiter) apply(cfg config.RateLimitConfig) error {
+	rules, err := cfg.ParseRules()
+	if err != nil {
+		return err
+	}
+	l.rules.Store(&rules)
+	l.enabled.Store(cfg.Enabled)
+	return nil
+}
+
+// Handler must run after Auth for the user limits to apply.
+func (l *RateLimiter) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !l.enabled.Load() {
+			return c.Next()
+		}
+
+		rule, ok := l.match(c.Method(), c.Path())
+		if !ok {
+			return c.Next()
+		}
+		route := rule.Method + " " + rule.Path
+
+		if rule.User.Requests > 0 {
+			if userID, err := ctxutil.GetUserIDCtx(c.Context()); err == nil {
+				key := "user:" + strconv.FormatInt(userID, 10) + ":" + route
+				if !l.allow(c, key, rule.User) {
+					return c.Status(fiber.StatusTooManyRequests).JSON(response.Error(domain.ErrTooManyRequest))
+				}
+			}
+		}
+
+		if rule.IP.Requests > 0 {
+			key := "ip:" + c.IP() + ":" + route
+			if !l.allow(c, key, rule.IP) {
+				return c.Status(fiber.StatusTooManyRequests).JSON(response.Error(domain.ErrTooManyRequest))
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// allow counts the request and sets the rate limit headers. A failing store
+// lets the request through, losing the limit is better than losing orders.
+func (l *RateLimiter) allow(c *fiber.Ctx, key string, limit config.RateLimit) bool {
+	count, resetAt, err := l.store.Increment(c.Context(), key, limit.Window)
+	if err != nil {
+		slog.ErrorContext(c.Context(), "[RateLimiter] Increment", "error", err)
+		return true
+	}
+
+	remaining := limit.Requests - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	c.Set("X-RateLimit-Limit", strconv.FormatInt(limit.Requests, 10))
+	c.Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+
+	if count <= limit.Requests {
+		return true
+	}
+
+	retryAfter := int64(math.Ceil(time.Until(resetAt).Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(retryAfter, 10))
+	slog.WarnContext(c.Context(), "[RateLimiter] limit exceeded", "key", key)
+	return false
+}
+
+func (l *RateLimiter) match(method, path string) (config.RateLimitRule, bool) {
+	for _, rule := range *l.rules.Load() {
+		if rule.Method == method && matchPath(rule.Path, path) {
+			return rule, true
+		}
+	}
+	return config.RateLimitRule{}, false
+}
+
+// matchPath matches a request path against a route pattern where ":name"
+// segments match any single non empty segment.
+func matchPath(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, seg := range patternSegments {
+		if strings.HasPrefix(seg, ":") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if seg != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import "testing"
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{pattern: "/order-service/orders", path: "/order-service/orders", want: true},
+		{pattern: "/order-service/orders", path: "/order-service/orders/", want: true},
+		{pattern: "/order-service/orders/:id/cancel", path: "/order-service/orders/42/cancel", want: true},
+		{pattern: "/order-service/orders/:id/cancel", path: "/order-service/orders//cancel"},
+		{pattern: "/order-service/orders/:id/cancel", path: "/order-service/orders/42/pay"},
+		{pattern: "/order-service/orders/:id", path: "/order-service/orders/42/cancel"},
+		{pattern: "/order-service/orders", path: "/order-service/order"},
+	}
+	for _, tt := range tests {
+		if got := matchPath(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
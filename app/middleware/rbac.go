@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/app/handler/response"
+	"order-service/pkg/ctxutil"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequirePermission must run after Auth, it rejects tokens whose roles and
+// permissions do not grant p.
+func RequirePermission(p domain.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		roles := ctxutil.GetRolesCtx(c.Context())
+		perms := ctxutil.GetPermsCtx(c.Context())
+		if !domain.HasPermission(roles, perms, p) {
+			slog.ErrorContext(c.Context(), "[middleware] RequirePermission", "missing permission", string(p))
+			return c.Status(fiber.StatusForbidden).JSON(response.Error(domain.ErrForbidden))
+		}
+		return c.Next()
+	}
+}
@@ -22,3 +22,11 @@ func RequestIDMiddleware() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// SourceIPMiddleware stores the client IP for the audit log.
+func SourceIPMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals(ctxutil.SourceIPKey, c.IP())
+		return c.Next()
+	}
+}
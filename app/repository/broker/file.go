@@ -0,0 +1,63 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"order-service/app/domain"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileRecord is one line of the NDJSON sink.
+type fileRecord struct {
+	ID          string          `json:"id"`
+	Topic       string          `json:"topic"`
+	Key         string          `json:"key,omitempty"`
+	Payload     json.RawMessage `json:"payload"`
+	PublishedAt time.Time       `json:"published_at"`
+}
+
+type filePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFilePublisher appends every message as a JSON line to path, for local
+// runs without a broker.
+func NewFilePublisher(path string) (domain.EventPublisher, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &filePublisher{file: file}, nil
+}
+
+func (p *filePublisher) Publish(ctx context.Context, msg domain.Message) error {
+	line, err := json.Marshal(fileRecord{
+		ID:          msg.ID,
+		Topic:       msg.Topic,
+		Key:         msg.Key,
+		Payload:     msg.Payload,
+		PublishedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[filePublisher] Publish", "error json Marshal", err)
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		slog.ErrorContext(ctx, "[filePublisher] Publish", "error file Write", err)
+		return err
+	}
+	return nil
+}
+
+func (p *filePublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.file.Close()
+}
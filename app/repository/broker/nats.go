@@ -0,0 +1,163 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// KeyHeader carries the message key, NATS has no key of its own.
+const KeyHeader = "Order-Service-Key"
+
+type natsPublisher struct {
+	conn *nats.Conn
+	js   jetstream.JetStream
+}
+
+// NewNATSPublisher connects to a NATS server and makes sure the JetStream
+// stream capturing "<subjectPrefix>.>" exists. Publishing waits for the
+// stream acknowledgement, and the message ID is used for JetStream
+// deduplication, so a message relayed twice is stored once.
+func NewNATSPublisher(ctx context.Context, url, stream, subjectPrefix string) (domain.EventPublisher, error) {
+	conn, err := nats.Connect(url, nats.Name("order-service"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("connect nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("init jetstream: %w", err)
+	}
+
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       stream,
+		Subjects:   []string{subjectPrefix + ".>"},
+		Duplicates: 10 * time.Minute,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("create stream %s: %w", stream, err)
+	}
+
+	return &natsPublisher{conn: conn, js: js}, nil
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, msg domain.Message) error {
+	natsMsg := nats.NewMsg(msg.Topic)
+	natsMsg.Data = msg.Payload
+	if msg.Key != "" {
+		natsMsg.Header.Set(KeyHeader, msg.Key)
+	}
+
+	if _, err := p.js.PublishMsg(ctx, natsMsg, jetstream.WithMsgID(msg.ID)); err != nil {
+		slog.ErrorContext(ctx, "[natsPublisher] Publish", "error PublishMsg", err)
+		return err
+	}
+	return nil
+}
+
+func (p *natsPublisher) Close() error {
+	return p.conn.Drain()
+}
+
+// maxRedeliveryDelay caps the delay before a failed message is delivered again.
+const maxRedeliveryDelay = time.Minute
+
+type natsSubscriber struct {
+	conn         *nats.Conn
+	js           jetstream.JetStream
+	consumerName string
+}
+
+// NewNATSSubscriber consumes topics through durable JetStream consumers named
+// after consumerName and the topic, so processing resumes where it stopped
+// after a restart. The streams holding the topics are owned by the producers.
+func NewNATSSubscriber(url, consumerName string) (domain.EventSubscriber, error) {
+	conn, err := nats.Connect(url, nats.Name(consumerName), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("connect nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("init jetstream: %w", err)
+	}
+	return &natsSubscriber{conn: conn, js: js, consumerName: consumerName}, nil
+}
+
+func (s *natsSubscriber) Subscribe(ctx context.Context, topic string, handler domain.MessageHandler) error {
+	stream, err := s.js.StreamNameBySubject(ctx, topic)
+	if err != nil {
+		return fmt.Errorf("find stream of %s: %w", topic, err)
+	}
+
+	// consumer names may not contain dots or wildcards
+	durable := s.consumerName + "_" + strings.NewReplacer(".", "_", "*", "_", ">", "_").Replace(topic)
+	consumer, err := s.js.CreateOrUpdateConsumer(ctx, stream, jetstream.ConsumerConfig{
+		Durable:       durable,
+		FilterSubject: topic,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       30 * time.Second,
+		MaxAckPending: 1,
+	})
+	if err != nil {
+		return fmt.Errorf("create consumer %s: %w", durable, err)
+	}
+
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		s.handle(ctx, msg, handler)
+	})
+	if err != nil {
+		return fmt.Errorf("consume %s: %w", topic, err)
+	}
+	defer consumeCtx.Stop()
+
+	<-ctx.Done()
+	return nil
+}
+
+func (s *natsSubscriber) handle(ctx context.Context, msg jetstream.Msg, handler domain.MessageHandler) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		slog.ErrorContext(ctx, "[natsSubscriber] handle", "error Metadata", err)
+		_ = msg.Nak()
+		return
+	}
+
+	id := msg.Headers().Get(jetstream.MsgIDHeader)
+	if id == "" {
+		id = fmt.Sprintf("%s:%d", meta.Stream, meta.Sequence.Stream)
+	}
+	delivery := domain.Delivery{
+		Message: domain.Message{
+			ID:      id,
+			Topic:   msg.Subject(),
+			Key:     msg.Headers().Get(KeyHeader),
+			Payload: msg.Data(),
+		},
+		Attempt: int(meta.NumDelivered),
+	}
+
+	if err := handler(ctx, delivery); err != nil {
+		delay := min(time.Second<<min(delivery.Attempt, 16), maxRedeliveryDelay)
+		if err := msg.NakWithDelay(delay); err != nil {
+			slog.ErrorContext(ctx, "[natsSubscriber] handle", "error NakWithDelay", err)
+		}
+		return
+	}
+	if err := msg.Ack(); err != nil {
+		slog.ErrorContext(ctx, "[natsSubscriber] handle", "error Ack", err)
+	}
+}
+
+func (s *natsSubscriber) Close() error {
+	return s.conn.Drain()
+}
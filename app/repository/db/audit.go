@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"strings"
+)
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) domain.AuditRepository {
+	return &auditRepository{
+		db: db,
+	}
+}
+
+func (r *auditRepository) CreateAuditLog(ctx context.Context, log *domain.AuditLog, tx *sql.Tx) error {
+	query := `INSERT INTO audit_logs (actor_type, actor_id, action, resource_type, resource_id, order_id, before, after,
+			request_id, source_ip, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, now()) RETURNING id, created_at`
+	err := tx.QueryRowContext(ctx, query,
+		log.ActorType,
+		log.ActorID,
+		log.Action,
+		log.ResourceType,
+		log.ResourceID,
+		log.OrderID,
+		nullableJSON(log.Before),
+		nullableJSON(log.After),
+		log.RequestID,
+		log.SourceIP,
+	).Scan(&log.ID, &log.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "[auditRepository] CreateAuditLog", "failed to create audit log", err)
+		return err
+	}
+	return nil
+}
+
+func (r *auditRepository) SearchAuditLogs(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, error) {
+	conditions := []string{"TRUE"}
+	var args []any
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ActorType != "" {
+		add("actor_type = $%d", filter.ActorType)
+	}
+	if filter.ActorID != 0 {
+		add("actor_id = $%d", filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.ResourceType != "" {
+		add("resource_type = $%d", filter.ResourceType)
+	}
+	if filter.ResourceID != 0 {
+		add("resource_id = $%d", filter.ResourceID)
+	}
+	if filter.OrderID != 0 {
+		add("order_id = $%d", filter.OrderID)
+	}
+	if filter.RequestID != "" {
+		add("request_id = $%d", filter.RequestID)
+	}
+	if filter.CreatedFrom != "" {
+		add("created_at >= $%d::date", filter.CreatedFrom)
+	}
+	if filter.CreatedTo != "" {
+		add("created_at < $%d::date + 1", filter.CreatedTo)
+	}
+
+	filter.Normalize()
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query := fmt.Sprintf(`SELECT id, actor_type, actor_id, action, resource_type, resource_id, order_id, before, after,
+			request_id, source_ip, created_at
+		FROM audit_logs WHERE %s ORDER BY id DESC LIMIT $%d OFFSET $%d`,
+		strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "[auditRepository] SearchAuditLogs", "failed to search audit logs", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []domain.AuditLog{}
+	for rows.Next() {
+		var log domain.AuditLog
+		var before, after []byte
+		err := rows.Scan(
+			&log.ID,
+			&log.ActorType,
+			&log.ActorID,
+			&log.Action,
+			&log.ResourceType,
+			&log.ResourceID,
+			&log.OrderID,
+			&before,
+			&after,
+			&log.RequestID,
+			&log.SourceIP,
+			&log.CreatedAt,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "[auditRepository] SearchAuditLogs", "scan error", err)
+			return nil, err
+		}
+		log.Before = before
+		log.After = after
+		logs = append(logs, log)
+	}
+	return logs, rows.Err()
+}
+
+func nullableJSON(raw []byte) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
@@ -0,0 +1,240 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const couponColumns = `c.id, c.code, c.type, c.value, c.max_discount, c.min_subtotal, c.starts_at, c.ends_at,
+	c.usage_limit, c.per_user_limit, c.product_ids, c.active, c.created_at, c.updated_at,
+	(SELECT count(*) FROM coupon_redemptions r WHERE r.coupon_id = c.id AND r.status = 'redeemed')`
+
+type couponRepository struct {
+	db      *sql.DB
+	typeMap *pgtype.Map
+}
+
+func NewCouponRepository(db *sql.DB) domain.CouponRepository {
+	return &couponRepository{
+		db:      db,
+		typeMap: pgtype.NewMap(),
+	}
+}
+
+func (r *couponRepository) scanCoupon(row rowScanner, coupon *domain.Coupon) error {
+	productIDs := []int64{}
+	err := row.Scan(
+		&coupon.ID,
+		&coupon.Code,
+		&coupon.Type,
+		&coupon.Value,
+		&coupon.MaxDiscount,
+		&coupon.MinSubtotal,
+		&coupon.StartsAt,
+		&coupon.EndsAt,
+		&coupon.UsageLimit,
+		&coupon.PerUserLimit,
+		r.typeMap.SQLScanner(&productIDs),
+		&coupon.Active,
+		&coupon.CreatedAt,
+		&coupon.UpdatedAt,
+		&coupon.Redemptions,
+	)
+	coupon.ProductIDs = productIDs
+	return err
+}
+
+// isUniqueViolation reports whether err was caused by a unique constraint.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (r *couponRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return withTransaction(ctx, r.db, fn)
+}
+
+func (r *couponRepository) CreateCoupon(ctx context.Context, coupon *domain.Coupon, tx *sql.Tx) error {
+	query := `INSERT INTO coupons (code, type, value, max_discount, min_subtotal, starts_at, ends_at, usage_limit, per_user_limit,
+			product_ids, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, now(), now()) RETURNING id, created_at, updated_at`
+	err := tx.QueryRowContext(ctx, query,
+		coupon.Code,
+		coupon.Type,
+		coupon.Value,
+		coupon.MaxDiscount,
+		coupon.MinSubtotal,
+		coupon.StartsAt,
+		coupon.EndsAt,
+		coupon.UsageLimit,
+		coupon.PerUserLimit,
+		coupon.ProductIDs,
+		coupon.Active,
+	).Scan(&coupon.ID, &coupon.CreatedAt, &coupon.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w: coupon code %s exists", domain.ErrConflict, coupon.Code)
+		}
+		slog.ErrorContext(ctx, "[couponRepository] CreateCoupon", "failed to create coupon", err)
+		return err
+	}
+	return nil
+}
+
+func (r *couponRepository) GetCouponByID(ctx context.Context, id int64) (domain.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons c WHERE c.id = $1`
+	coupon := domain.Coupon{}
+	if err := r.scanCoupon(r.db.QueryRowContext(ctx, query, id), &coupon); err != nil {
+		if err == sql.ErrNoRows {
+			slog.ErrorContext(ctx, "[couponRepository] GetCouponByID", "coupon not found", err)
+			return coupon, domain.ErrNotFound
+		}
+		slog.ErrorContext(ctx, "[couponRepository] GetCouponByID", "failed to get coupon", err)
+		return coupon, err
+	}
+	return coupon, nil
+}
+
+func (r *couponRepository) GetCouponByIDForUpdate(ctx context.Context, id int64, tx *sql.Tx) (domain.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons c WHERE c.id = $1 FOR UPDATE`
+	coupon := domain.Coupon{}
+	if err := r.scanCoupon(tx.QueryRowContext(ctx, query, id), &coupon); err != nil {
+		if err == sql.ErrNoRows {
+			return coupon, domain.ErrNotFound
+		}
+		slog.ErrorContext(ctx, "[couponRepository] GetCouponByIDForUpdate", "failed to get coupon", err)
+		return coupon, err
+	}
+	return coupon, nil
+}
+
+func (r *couponRepository) ListCoupons(ctx context.Context, filter domain.CouponFilter) ([]domain.Coupon, error) {
+	filter.Normalize()
+	query := `SELECT ` + couponColumns + ` FROM coupons c ORDER BY c.id DESC LIMIT $1 OFFSET $2`
+	rows, err := r.db.QueryContext(ctx, query, filter.Limit, (filter.Page-1)*filter.Limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "[couponRepository] ListCoupons", "failed to list coupons", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	coupons := []domain.Coupon{}
+	for rows.Next() {
+		coupon := domain.Coupon{}
+		if err := r.scanCoupon(rows, &coupon); err != nil {
+			slog.ErrorContext(ctx, "[couponRepository] ListCoupons", "scan error", err)
+			return nil, err
+		}
+		coupons = append(coupons, coupon)
+	}
+	return coupons, rows.Err()
+}
+
+func (r *couponRepository) UpdateCoupon(ctx context.Context, coupon *domain.Coupon, tx *sql.Tx) error {
+	query := `UPDATE coupons
+		SET code = $1, type = $2, value = $3, max_discount = $4, min_subtotal = $5, starts_at = $6, ends_at = $7,
+			usage_limit = $8, per_user_limit = $9, product_ids = $10, active = $11, updated_at = now()
+		WHERE id = $12 RETURNING created_at, updated_at`
+	err := tx.QueryRowContext(ctx, query,
+		coupon.Code,
+		coupon.Type,
+		coupon.Value,
+		coupon.MaxDiscount,
+		coupon.MinSubtotal,
+		coupon.StartsAt,
+		coupon.EndsAt,
+		coupon.UsageLimit,
+		coupon.PerUserLimit,
+		coupon.ProductIDs,
+		coupon.Active,
+		coupon.ID,
+	).Scan(&coupon.CreatedAt, &coupon.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrNotFound
+		}
+		if isUniqueViolation(err) {
+			return fmt.Errorf("%w: coupon code %s exists", domain.ErrConflict, coupon.Code)
+		}
+		slog.ErrorContext(ctx, "[couponRepository] UpdateCoupon", "failed to update coupon", err)
+		return err
+	}
+	return nil
+}
+
+func (r *couponRepository) GetCouponByCodeForUpdate(ctx context.Context, code string, tx *sql.Tx) (domain.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons c WHERE c.code = $1 FOR UPDATE`
+	coupon := domain.Coupon{}
+	if err := r.scanCoupon(tx.QueryRowContext(ctx, query, code), &coupon); err != nil {
+		if err == sql.ErrNoRows {
+			return coupon, domain.ErrNotFound
+		}
+		slog.ErrorContext(ctx, "[couponRepository] GetCouponByCodeForUpdate", "failed to get coupon", err)
+		return coupon, err
+	}
+	return coupon, nil
+}
+
+func (r *couponRepository) CountUserRedemptions(ctx context.Context, couponID, userID int64, tx *sql.Tx) (int64, error) {
+	query := `SELECT count(*) FROM coupon_redemptions WHERE coupon_id = $1 AND user_id = $2 AND status = $3`
+	var count int64
+	if err := tx.QueryRowContext(ctx, query, couponID, userID, domain.CouponRedemptionRedeemed).Scan(&count); err != nil {
+		slog.ErrorContext(ctx, "[couponRepository] CountUserRedemptions", "failed to count redemptions", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *couponRepository) CreateRedemption(ctx context.Context, redemption *domain.CouponRedemption, tx *sql.Tx) error {
+	query := `INSERT INTO coupon_redemptions (coupon_id, order_id, user_id, code, type, item_discount, shipping_discount, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now()) RETURNING id, created_at`
+	err := tx.QueryRowContext(ctx, query,
+		redemption.CouponID,
+		redemption.OrderID,
+		redemption.UserID,
+		redemption.Code,
+		redemption.Type,
+		redemption.ItemDiscount,
+		redemption.ShippingDiscount,
+		redemption.Status,
+	).Scan(&redemption.ID, &redemption.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "[couponRepository] CreateRedemption", "failed to create redemption", err)
+		return err
+	}
+	return nil
+}
+
+func (r *couponRepository) UpdateRedemptionDiscount(ctx context.Context, orderID, itemDiscount, shippingDiscount int64, tx *sql.Tx) error {
+	query := `UPDATE coupon_redemptions SET item_discount = $1, shipping_discount = $2 WHERE order_id = $3 AND status = $4`
+	if _, err := tx.ExecContext(ctx, query, itemDiscount, shippingDiscount, orderID, domain.CouponRedemptionRedeemed); err != nil {
+		slog.ErrorContext(ctx, "[couponRepository] UpdateRedemptionDiscount", "failed to update redemption", err)
+		return err
+	}
+	return nil
+}
+
+func (r *couponRepository) ReleaseRedemption(ctx context.Context, orderID int64, tx *sql.Tx) error {
+	query := `UPDATE coupon_redemptions SET status = $1, released_at = now() WHERE order_id = $2 AND status = $3`
+	if _, err := tx.ExecContext(ctx, query, domain.CouponRedemptionReleased, orderID, domain.CouponRedemptionRedeemed); err != nil {
+		slog.ErrorContext(ctx, "[couponRepository] ReleaseRedemption", "failed to release redemption", err)
+		return err
+	}
+	return nil
+}
+
+func (r *couponRepository) RestoreRedemption(ctx context.Context, orderID int64, tx *sql.Tx) error {
+	query := `UPDATE coupon_redemptions SET status = $1, released_at = NULL WHERE order_id = $2 AND status = $3`
+	if _, err := tx.ExecContext(ctx, query, domain.CouponRedemptionRedeemed, orderID, domain.CouponRedemptionReleased); err != nil {
+		slog.ErrorContext(ctx, "[couponRepository] RestoreRedemption", "failed to restore redemption", err)
+		return err
+	}
+	return nil
+}
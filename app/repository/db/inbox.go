@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"order-service/app/domain"
+)
+
+type inboxRepository struct {
+	db *sql.DB
+}
+
+func NewInboxRepository(db *sql.DB) domain.InboxRepository {
+	return &inboxRepository{db: db}
+}
+
+func (r *inboxRepository) MarkMessageProcessed(ctx context.Context, msg domain.Message, tx *sql.Tx) (bool, error) {
+	query := `INSERT INTO processed_messages (message_id, topic, processed_at) VALUES ($1, $2, now())
+		ON CONFLICT (message_id) DO NOTHING`
+	res, err := tx.ExecContext(ctx, query, msg.ID, msg.Topic)
+	if err != nil {
+		slog.ErrorContext(ctx, "[inboxRepository] MarkMessageProcessed", "failed to insert processed message", err)
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+func (r *inboxRepository) CreateDeadLetter(ctx context.Context, deadLetter *domain.DeadLetter) error {
+	query := `INSERT INTO dead_letter_messages (message_id, topic, payload, attempts, error, created_at)
+		VALUES ($1, $2, $3, $4, $5, now()) RETURNING id, created_at`
+	err := r.db.QueryRowContext(ctx, query,
+		deadLetter.MessageID,
+		deadLetter.Topic,
+		[]byte(deadLetter.Payload),
+		deadLetter.Attempts,
+		deadLetter.Error,
+	).Scan(&deadLetter.ID, &deadLetter.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "[inboxRepository] CreateDeadLetter", "failed to create dead letter", err)
+		return err
+	}
+	return nil
+}
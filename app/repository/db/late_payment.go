@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"strings"
+)
+
+type latePaymentRepository struct {
+	db *sql.DB
+}
+
+func NewLatePaymentRepository(db *sql.DB) domain.LatePaymentRepository {
+	return &latePaymentRepository{
+		db: db,
+	}
+}
+
+func (r *latePaymentRepository) CreateLatePayment(ctx context.Context, payment *domain.LatePayment, tx *sql.Tx) error {
+	query := `INSERT INTO late_payments (order_id, payment_id, outcome, reason, created_at)
+		VALUES ($1, $2, $3, $4, now()) RETURNING id, created_at`
+	err := tx.QueryRowContext(ctx, query, payment.OrderID, payment.PaymentID, payment.Outcome, payment.Reason).
+		Scan(&payment.ID, &payment.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "[latePaymentRepository] CreateLatePayment", "failed to create late payment", err)
+		return err
+	}
+	return nil
+}
+
+func (r *latePaymentRepository) ListLatePayments(ctx context.Context, filter domain.LatePaymentFilter) ([]domain.LatePayment, error) {
+	conditions := []string{"TRUE"}
+	var args []any
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Outcome != "" {
+		add("outcome = $%d", filter.Outcome)
+	}
+	if filter.OrderID != 0 {
+		add("order_id = $%d", filter.OrderID)
+	}
+
+	filter.Normalize()
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query := fmt.Sprintf(`SELECT id, order_id, payment_id, outcome, reason, created_at
+		FROM late_payments WHERE %s ORDER BY id DESC LIMIT $%d OFFSET $%d`,
+		strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "[latePaymentRepository] ListLatePayments", "failed to list late payments", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []domain.LatePayment{}
+	for rows.Next() {
+		var payment domain.LatePayment
+		err := rows.Scan(&payment.ID, &payment.OrderID, &payment.PaymentID, &payment.Outcome, &payment.Reason, &payment.CreatedAt)
+		if err != nil {
+			slog.ErrorContext(ctx, "[latePaymentRepository] ListLatePayments", "scan error", err)
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, rows.Err()
+}
@@ -3,11 +3,119 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"log/slog"
 	"order-service/app/domain"
+	"strings"
 	"time"
 )
 
+const orderColumns = `o.id, o.product_id, o.quantity, o.user_id, o.shop_id, o.status, o.notes, o.created_at, o.updated_at,
+	o.expired_at, o.expiry_extended_at, o.courier, o.tracking_number, o.shipped_at, o.version, o.delivery_method,
+	s.recipient_name, s.phone, s.address_line1, s.address_line2, s.city, s.postal_code, s.country,
+	o.unit_price, o.subtotal, o.shipping_fee, o.discount, o.tax, o.total, o.refunded_quantity, o.refunded_amount,
+	r.coupon_id, r.code, r.type, r.item_discount, r.shipping_discount, r.status, r.released_at,
+	(SELECT COALESCE(json_agg(json_build_object('component', t.component, 'name', t.name, 'region', t.region,
+		'category', t.category, 'rate_bps', t.rate_bps, 'inclusive', t.inclusive, 'taxable_amount', t.taxable_amount,
+		'amount', t.amount) ORDER BY t.id), '[]') FROM order_tax_lines t WHERE t.order_id = o.id)`
+
+// orderTables joins the shipping address, which pickup orders do not have,
+// and the coupon redemption. Row locks must name orders, the other sides of
+// the joins can be null.
+const orderTables = `orders o LEFT JOIN order_shipping_addresses s ON s.order_id = o.id
+	LEFT JOIN coupon_redemptions r ON r.order_id = o.id`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOrder(row rowScanner, order *domain.Order) error {
+	var recipientName, phone, addressLine1, addressLine2, city, postalCode, country sql.NullString
+	var couponID, itemDiscount, shippingDiscount sql.NullInt64
+	var couponCode, couponType, redemptionStatus sql.NullString
+	var releasedAt sql.NullTime
+	var taxLines []byte
+	err := row.Scan(
+		&order.ID,
+		&order.ProductID,
+		&order.Quantity,
+		&order.UserID,
+		&order.ShopID,
+		&order.Status,
+		&order.Notes,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+		&order.ExpiredAt,
+		&order.ExpiryExtendedAt,
+		&order.Courier,
+		&order.TrackingNumber,
+		&order.ShippedAt,
+		&order.Version,
+		&order.DeliveryMethod,
+		&recipientName,
+		&phone,
+		&addressLine1,
+		&addressLine2,
+		&city,
+		&postalCode,
+		&country,
+		&order.Pricing.UnitPrice,
+		&order.Pricing.Subtotal,
+		&order.Pricing.ShippingFee,
+		&order.Pricing.Discount,
+		&order.Pricing.Tax,
+		&order.Pricing.Total,
+		&order.RefundedQuantity,
+		&order.RefundedAmount,
+		&couponID,
+		&couponCode,
+		&couponType,
+		&itemDiscount,
+		&shippingDiscount,
+		&redemptionStatus,
+		&releasedAt,
+		&taxLines,
+	)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(taxLines, &order.Pricing.TaxLines); err != nil {
+		return err
+	}
+
+	order.Pricing.Coupon = nil
+	if couponID.Valid {
+		order.Pricing.Coupon = &domain.CouponRedemption{
+			CouponID:         couponID.Int64,
+			OrderID:          order.ID,
+			UserID:           order.UserID,
+			Code:             couponCode.String,
+			Type:             domain.CouponType(couponType.String),
+			ItemDiscount:     itemDiscount.Int64,
+			ShippingDiscount: shippingDiscount.Int64,
+			Status:           domain.CouponRedemptionStatus(redemptionStatus.String),
+		}
+		if releasedAt.Valid {
+			order.Pricing.Coupon.ReleasedAt = &releasedAt.Time
+		}
+	}
+
+	order.ShippingAddress = nil
+	if recipientName.Valid {
+		order.ShippingAddress = &domain.ShippingAddress{
+			RecipientName: recipientName.String,
+			Phone:         phone.String,
+			AddressLine1:  addressLine1.String,
+			AddressLine2:  addressLine2.String,
+			City:          city.String,
+			PostalCode:    postalCode.String,
+			Country:       country.String,
+		}
+	}
+	return nil
+}
+
 type orderRepository struct {
 	db *sql.DB
 }
@@ -19,38 +127,70 @@ func NewOrderRepository(db *sql.DB) domain.OrderRepository {
 }
 
 func (r *orderRepository) CreateOrder(ctx context.Context, order *domain.Order, tx *sql.Tx) error {
-	query := `INSERT INTO orders (product_id, quantity, user_id, status, created_at, updated_at, expired_at) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	query := `INSERT INTO orders (product_id, quantity, user_id, shop_id, status, notes, created_at, updated_at, expired_at, delivery_method,
+			unit_price, subtotal, shipping_fee, discount, tax, total)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16) RETURNING id, created_at, updated_at, version`
 	err := tx.QueryRowContext(ctx, query,
 		order.ProductID,
 		order.Quantity,
 		order.UserID,
+		order.ShopID,
 		order.Status,
+		order.Notes,
 		time.Now(),
 		time.Now(),
 		order.ExpiredAt,
-	).Scan(&order.ID)
+		order.DeliveryMethod,
+		order.Pricing.UnitPrice,
+		order.Pricing.Subtotal,
+		order.Pricing.ShippingFee,
+		order.Pricing.Discount,
+		order.Pricing.Tax,
+		order.Pricing.Total,
+	).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt, &order.Version)
 	if err != nil {
 		slog.ErrorContext(ctx, "[orderRepository] CreateOrder", "failed to create order", err)
 		return err
 	}
+	if order.ShippingAddress != nil {
+		if err := r.upsertShippingAddress(ctx, order.ID, *order.ShippingAddress, tx); err != nil {
+			return err
+		}
+	}
+	return r.createTaxLines(ctx, order.ID, order.Pricing.TaxLines, tx)
+}
+
+// createTaxLines stores the tax lines of an order. They are only replaced
+// when the quantity or the delivery of an unpaid order is edited, so invoices
+// show the rates the order was last priced with.
+func (r *orderRepository) createTaxLines(ctx context.Context, orderID int64, lines []domain.TaxLine, tx *sql.Tx) error {
+	query := `INSERT INTO order_tax_lines (order_id, component, name, region, category, rate_bps, inclusive, taxable_amount, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, now())`
+	for _, line := range lines {
+		_, err := tx.ExecContext(ctx, query,
+			orderID,
+			line.Component,
+			line.Name,
+			line.Region,
+			line.Category,
+			line.RateBasisPoints,
+			line.Inclusive,
+			line.TaxableAmount,
+			line.Amount,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "[orderRepository] createTaxLines", "failed to create tax line", err)
+			return err
+		}
+	}
 	return nil
 }
 
 func (r *orderRepository) GetOrderByID(ctx context.Context, id int64) (domain.Order, error) {
-	query := `SELECT id, product_id, quantity, user_id, status, created_at, updated_at, expired_at
-		FROM orders WHERE id = $1`
+	query := `SELECT ` + orderColumns + `
+		FROM ` + orderTables + ` WHERE o.id = $1`
 	order := domain.Order{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&order.ID,
-		&order.ProductID,
-		&order.Quantity,
-		&order.UserID,
-		&order.Status,
-		&order.CreatedAt,
-		&order.UpdatedAt,
-		&order.ExpiredAt,
-	)
+	err := scanOrder(r.db.QueryRowContext(ctx, query, id), &order)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			slog.ErrorContext(ctx, "[orderRepository] GetOrderByID", "order not found", err)
@@ -62,19 +202,219 @@ func (r *orderRepository) GetOrderByID(ctx context.Context, id int64) (domain.Or
 	return order, nil
 }
 
-func (r *orderRepository) UpdateStatusOrder(ctx context.Context, id int64, status string, tx *sql.Tx) error {
-	query := `UPDATE orders SET status = $1, updated_at = now() WHERE id = $2`
-	_, err := tx.ExecContext(ctx, query, status, id)
+// GetOrderByIDForUpdate locks the order row until tx ends, so status checks
+// and the following update cannot interleave with another writer.
+func (r *orderRepository) GetOrderByIDForUpdate(ctx context.Context, id int64, tx *sql.Tx) (domain.Order, error) {
+	query := `SELECT ` + orderColumns + `
+		FROM ` + orderTables + ` WHERE o.id = $1 FOR UPDATE OF o`
+	order := domain.Order{}
+	err := scanOrder(tx.QueryRowContext(ctx, query, id), &order)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			slog.ErrorContext(ctx, "[orderRepository] GetOrderByIDForUpdate", "order not found", err)
+			return order, domain.ErrNotFound
+		}
+		slog.ErrorContext(ctx, "[orderRepository] GetOrderByIDForUpdate", "failed to get order by ID", err)
+		return order, err
+	}
+	return order, nil
+}
+
+// UpdateStatusOrder only applies when the order is still at version, so a
+// change made since it was read is reported as domain.ErrVersionConflict
+// instead of being overwritten.
+func (r *orderRepository) UpdateStatusOrder(ctx context.Context, id, version int64, status string, tx *sql.Tx) error {
+	query := `UPDATE orders SET status = $1, version = version + 1, updated_at = now() WHERE id = $2 AND version = $3`
+	res, err := tx.ExecContext(ctx, query, status, id, version)
 	if err != nil {
 		slog.ErrorContext(ctx, "[orderRepository] UpdateStatusOrder", "failed to update order status", err)
 		return err
 	}
+	return checkVersionedUpdate(ctx, "UpdateStatusOrder", res)
+}
+
+func (r *orderRepository) ApplyRefund(ctx context.Context, id, version int64, refund domain.Refund, status domain.OrderStatus, tx *sql.Tx) error {
+	query := `UPDATE orders SET status = $1, refunded_quantity = refunded_quantity + $2, refunded_amount = refunded_amount + $3,
+			version = version + 1, updated_at = now()
+		WHERE id = $4 AND version = $5`
+	res, err := tx.ExecContext(ctx, query, status, refund.Quantity, refund.Amount, id, version)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] ApplyRefund", "failed to apply refund", err)
+		return err
+	}
+	return checkVersionedUpdate(ctx, "ApplyRefund", res)
+}
+
+func (r *orderRepository) UpdateShipment(ctx context.Context, id, version int64, req domain.OrderShipRequest, tx *sql.Tx) error {
+	query := `UPDATE orders SET status = $1, courier = $2, tracking_number = $3, shipped_at = now(), version = version + 1, updated_at = now()
+		WHERE id = $4 AND version = $5`
+	res, err := tx.ExecContext(ctx, query, domain.OrderStatusShipped, req.Courier, req.TrackingNumber, id, version)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] UpdateShipment", "failed to update order shipment", err)
+		return err
+	}
+	return checkVersionedUpdate(ctx, "UpdateShipment", res)
+}
+
+// checkVersionedUpdate turns an update that matched no row into
+// domain.ErrVersionConflict.
+func checkVersionedUpdate(ctx context.Context, method string, res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] "+method, "rows affected", err)
+		return err
+	}
+	if n == 0 {
+		slog.ErrorContext(ctx, "[orderRepository] "+method, "version mismatch", domain.ErrVersionConflict)
+		return domain.ErrVersionConflict
+	}
+	return nil
+}
+
+func (r *orderRepository) UpdateShipping(ctx context.Context, id, version int64, order domain.Order, tx *sql.Tx) error {
+	query := `UPDATE orders SET delivery_method = $1, shipping_fee = $2, discount = $3, tax = $4, total = $5,
+			version = version + 1, updated_at = now()
+		WHERE id = $6 AND version = $7`
+	res, err := tx.ExecContext(ctx, query,
+		order.DeliveryMethod,
+		order.Pricing.ShippingFee,
+		order.Pricing.Discount,
+		order.Pricing.Tax,
+		order.Pricing.Total,
+		id,
+		version,
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] UpdateShipping", "failed to update delivery method", err)
+		return err
+	}
+	if err := checkVersionedUpdate(ctx, "UpdateShipping", res); err != nil {
+		return err
+	}
+	if err := r.replaceTaxLines(ctx, id, order.Pricing.TaxLines, tx); err != nil {
+		return err
+	}
+
+	if order.ShippingAddress != nil {
+		return r.upsertShippingAddress(ctx, id, *order.ShippingAddress, tx)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM order_shipping_addresses WHERE order_id = $1`, id); err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] UpdateShipping", "failed to delete shipping address", err)
+		return err
+	}
 	return nil
 }
 
+func (r *orderRepository) UpdateQuantity(ctx context.Context, id, version int64, order domain.Order, tx *sql.Tx) error {
+	query := `UPDATE orders SET quantity = $1, subtotal = $2, discount = $3, tax = $4, total = $5, expired_at = $6,
+			expiry_extended_at = $7, version = version + 1, updated_at = now()
+		WHERE id = $8 AND version = $9`
+	res, err := tx.ExecContext(ctx, query,
+		order.Quantity,
+		order.Pricing.Subtotal,
+		order.Pricing.Discount,
+		order.Pricing.Tax,
+		order.Pricing.Total,
+		order.ExpiredAt,
+		order.ExpiryExtendedAt,
+		id,
+		version,
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] UpdateQuantity", "failed to update quantity", err)
+		return err
+	}
+	if err := checkVersionedUpdate(ctx, "UpdateQuantity", res); err != nil {
+		return err
+	}
+	return r.replaceTaxLines(ctx, id, order.Pricing.TaxLines, tx)
+}
+
+func (r *orderRepository) replaceTaxLines(ctx context.Context, orderID int64, lines []domain.TaxLine, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM order_tax_lines WHERE order_id = $1`, orderID); err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] replaceTaxLines", "failed to delete tax lines", err)
+		return err
+	}
+	return r.createTaxLines(ctx, orderID, lines, tx)
+}
+
+func (r *orderRepository) ExtendExpiry(ctx context.Context, id, version int64, expiredAt time.Time, tx *sql.Tx) error {
+	query := `UPDATE orders SET expired_at = $1, expiry_extended_at = now(), version = version + 1, updated_at = now()
+		WHERE id = $2 AND version = $3`
+	res, err := tx.ExecContext(ctx, query, expiredAt, id, version)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] ExtendExpiry", "failed to extend expiry", err)
+		return err
+	}
+	return checkVersionedUpdate(ctx, "ExtendExpiry", res)
+}
+
+func (r *orderRepository) upsertShippingAddress(ctx context.Context, orderID int64, address domain.ShippingAddress, tx *sql.Tx) error {
+	query := `INSERT INTO order_shipping_addresses
+			(order_id, recipient_name, phone, address_line1, address_line2, city, postal_code, country, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now(), now())
+		ON CONFLICT (order_id) DO UPDATE SET recipient_name = EXCLUDED.recipient_name, phone = EXCLUDED.phone,
+			address_line1 = EXCLUDED.address_line1, address_line2 = EXCLUDED.address_line2, city = EXCLUDED.city,
+			postal_code = EXCLUDED.postal_code, country = EXCLUDED.country, updated_at = now()`
+	_, err := tx.ExecContext(ctx, query,
+		orderID,
+		address.RecipientName,
+		address.Phone,
+		address.AddressLine1,
+		address.AddressLine2,
+		address.City,
+		address.PostalCode,
+		address.Country,
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] upsertShippingAddress", "failed to save shipping address", err)
+		return err
+	}
+	return nil
+}
+
+func (r *orderRepository) CreateOrderHistory(ctx context.Context, history *domain.OrderHistory, tx *sql.Tx) error {
+	query := `INSERT INTO order_histories (order_id, from_status, to_status, actor_type, actor_id, note, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now()) RETURNING id, created_at`
+	err := tx.QueryRowContext(ctx, query,
+		history.OrderID,
+		history.FromStatus,
+		history.ToStatus,
+		history.ActorType,
+		history.ActorID,
+		history.Note,
+	).Scan(&history.ID, &history.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] CreateOrderHistory", "failed to create order history", err)
+		return err
+	}
+	return nil
+}
+
+func (r *orderRepository) LockUserOrders(ctx context.Context, userID int64, tx *sql.Tx) error {
+	query := `SELECT pg_advisory_xact_lock(hashtext('orders:user:' || $1::text))`
+	if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] LockUserOrders", "failed to lock user orders", err)
+		return err
+	}
+	return nil
+}
+
+func (r *orderRepository) GetUnpaidOrderStats(ctx context.Context, userID, productID int64, tx *sql.Tx) (domain.UnpaidOrderStats, error) {
+	query := `SELECT COUNT(*), COALESCE(SUM(quantity) FILTER (WHERE product_id = $2), 0)
+		FROM orders WHERE user_id = $1 AND status = $3 AND expired_at > now()`
+	var stats domain.UnpaidOrderStats
+	err := tx.QueryRowContext(ctx, query, userID, productID, domain.OrderStatusWaitingPayment).Scan(&stats.OrderCount, &stats.ProductQuantity)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] GetUnpaidOrderStats", "failed to get unpaid order stats", err)
+		return stats, err
+	}
+	return stats, nil
+}
+
 func (r *orderRepository) GetListByUserID(ctx context.Context, userID int64) ([]domain.Order, error) {
-	query := `SELECT id, product_id, quantity, user_id, status, created_at, updated_at, expired_at
-		FROM orders WHERE user_id = $1`
+	query := `SELECT ` + orderColumns + `
+		FROM ` + orderTables + ` WHERE o.user_id = $1`
 	rows, err := r.db.QueryContext(ctx, query, userID)
 	if err != nil {
 		slog.ErrorContext(ctx, "[orderRepository] GetListByUserID", "failed to get orders by user ID", err)
@@ -85,17 +425,7 @@ func (r *orderRepository) GetListByUserID(ctx context.Context, userID int64) ([]
 	var orders []domain.Order
 	for rows.Next() {
 		order := domain.Order{}
-		err := rows.Scan(
-			&order.ID,
-			&order.ProductID,
-			&order.Quantity,
-			&order.UserID,
-			&order.Status,
-			&order.CreatedAt,
-			&order.UpdatedAt,
-			&order.ExpiredAt,
-		)
-		if err != nil {
+		if err := scanOrder(rows, &order); err != nil {
 			slog.ErrorContext(ctx, "[orderRepository] GetListByUserID", "scan error", err)
 			return nil, err
 		}
@@ -104,35 +434,116 @@ func (r *orderRepository) GetListByUserID(ctx context.Context, userID int64) ([]
 	return orders, nil
 }
 
+func (r *orderRepository) GetListByShopID(ctx context.Context, shopID int64, filter domain.OrderFilter) ([]domain.Order, error) {
+	filter.ShopID = shopID
+	orders, err := r.listOrders(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] GetListByShopID", "failed to get orders by shop ID", err)
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *orderRepository) SearchOrders(ctx context.Context, filter domain.OrderFilter) ([]domain.Order, error) {
+	orders, err := r.listOrders(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderRepository] SearchOrders", "failed to search orders", err)
+		return nil, err
+	}
+	return orders, nil
+}
+
+// listOrders returns one page of orders matching filter, newest first.
+func (r *orderRepository) listOrders(ctx context.Context, filter domain.OrderFilter) ([]domain.Order, error) {
+	conditions, args := appendFilterConditions([]string{"TRUE"}, nil, filter)
+
+	filter.Normalize()
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query := fmt.Sprintf(`SELECT %s
+		FROM %s WHERE %s ORDER BY o.created_at DESC, o.id DESC LIMIT $%d OFFSET $%d`,
+		orderColumns, orderTables, strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []domain.Order{}
+	for rows.Next() {
+		order := domain.Order{}
+		if err := scanOrder(rows, &order); err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, rows.Err()
+}
+
+// appendFilterConditions adds a placeholder condition for every filter field
+// that is set. Date bounds are inclusive days in UTC.
+func appendFilterConditions(conditions []string, args []any, filter domain.OrderFilter) ([]string, []any) {
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Status != "" {
+		add("o.status = $%d", filter.Status)
+	}
+	if filter.ProductID != 0 {
+		add("o.product_id = $%d", filter.ProductID)
+	}
+	if filter.UserID != 0 {
+		add("o.user_id = $%d", filter.UserID)
+	}
+	if filter.ShopID != 0 {
+		add("o.shop_id = $%d", filter.ShopID)
+	}
+	if filter.CreatedFrom != "" {
+		add("o.created_at >= $%d::date", filter.CreatedFrom)
+	}
+	if filter.CreatedTo != "" {
+		add("o.created_at < $%d::date + 1", filter.CreatedTo)
+	}
+	return conditions, args
+}
+
 func (r *orderRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
-	tx, err := r.db.BeginTx(ctx, nil)
+	return withTransaction(ctx, r.db, fn)
+}
+
+// withTransaction runs fn in a transaction that is committed when fn returns
+// nil and rolled back otherwise.
+func withTransaction(ctx context.Context, db *sql.DB, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		slog.ErrorContext(ctx, "[orderRepository] WithTransaction", "failed to begin transaction", err)
+		slog.ErrorContext(ctx, "[db] withTransaction", "failed to begin transaction", err)
 		return err
 	}
 
 	defer func() {
 		if err != nil {
 			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
-				slog.ErrorContext(ctx, "[orderRepository] WithTransaction", "failed to rollback transaction", err)
+				slog.ErrorContext(ctx, "[db] withTransaction", "failed to rollback transaction", err)
 			}
 		}
 	}()
 
-	if err := fn(ctx, tx); err != nil {
-		slog.ErrorContext(ctx, "[orderRepository] WithTransaction", "function error", err)
+	if err = fn(ctx, tx); err != nil {
+		slog.ErrorContext(ctx, "[db] withTransaction", "function error", err)
 		return err
 	}
 	if err := tx.Commit(); err != nil {
-		slog.ErrorContext(ctx, "[orderRepository] WithTransaction", "failed to commit transaction", err)
+		slog.ErrorContext(ctx, "[db] withTransaction", "failed to commit transaction", err)
 		return err
 	}
 	return nil
 }
 
 func (r *orderRepository) GetExpiredOrders(ctx context.Context) ([]domain.Order, error) {
-	query := `SELECT id, product_id, quantity, user_id, status, created_at, updated_at, expired_at
-		FROM orders WHERE status = 'waiting_payment' AND expired_at < now()`
+	query := `SELECT ` + orderColumns + `
+		FROM ` + orderTables + ` WHERE o.status = 'waiting_payment' AND o.expired_at < now()`
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		slog.ErrorContext(ctx, "[orderRepository] GetExpiredOrders", "failed to get expired orders", err)
@@ -143,17 +554,7 @@ func (r *orderRepository) GetExpiredOrders(ctx context.Context) ([]domain.Order,
 	var orders []domain.Order
 	for rows.Next() {
 		order := domain.Order{}
-		err := rows.Scan(
-			&order.ID,
-			&order.ProductID,
-			&order.Quantity,
-			&order.UserID,
-			&order.Status,
-			&order.CreatedAt,
-			&order.UpdatedAt,
-			&order.ExpiredAt,
-		)
-		if err != nil {
+		if err := scanOrder(rows, &order); err != nil {
 			slog.ErrorContext(ctx, "[orderRepository] GetExpiredOrders", "scan error", err)
 			return nil, err
 		}
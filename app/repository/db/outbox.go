@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"order-service/app/domain"
+	"sort"
+	"time"
+)
+
+type outboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) domain.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) CreateOutboxEvent(ctx context.Context, event *domain.OutboxEvent, tx *sql.Tx) error {
+	query := `INSERT INTO outbox_events (message_id, topic, message_key, payload, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, now(), now()) RETURNING id, next_attempt_at, created_at`
+	msg := event.Message
+	err := tx.QueryRowContext(ctx, query, msg.ID, msg.Topic, msg.Key, string(msg.Payload)).
+		Scan(&event.ID, &event.NextAttemptAt, &event.CreatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "[outboxRepository] CreateOutboxEvent", "failed to create outbox event", err)
+		return err
+	}
+	return nil
+}
+
+func (r *outboxRepository) ClaimPendingOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	query := `WITH due AS (
+			SELECT id FROM outbox_events
+			WHERE published_at IS NULL AND next_attempt_at <= now()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE outbox_events o SET next_attempt_at = now() + make_interval(secs => $2)
+		FROM due
+		WHERE o.id = due.id
+		RETURNING o.id, o.message_id, o.topic, o.message_key, o.payload, o.attempts, o.next_attempt_at, o.last_error, o.created_at`
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		slog.ErrorContext(ctx, "[outboxRepository] ClaimPendingOutboxEvents", "failed to claim outbox events", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []domain.OutboxEvent
+	for rows.Next() {
+		event := domain.OutboxEvent{}
+		err := rows.Scan(
+			&event.ID,
+			&event.Message.ID,
+			&event.Message.Topic,
+			&event.Message.Key,
+			&event.Message.Payload,
+			&event.Attempts,
+			&event.NextAttemptAt,
+			&event.LastError,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "[outboxRepository] ClaimPendingOutboxEvents", "scan error", err)
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the CTE
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+func (r *outboxRepository) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	query := `UPDATE outbox_events SET published_at = now(), attempts = attempts + 1, last_error = '' WHERE id = $1`
+	if _, err := r.db.ExecContext(ctx, query, id); err != nil {
+		slog.ErrorContext(ctx, "[outboxRepository] MarkOutboxEventPublished", "failed to update outbox event", err)
+		return err
+	}
+	return nil
+}
+
+func (r *outboxRepository) MarkOutboxEventFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	query := `UPDATE outbox_events SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2 WHERE id = $3`
+	if _, err := r.db.ExecContext(ctx, query, lastError, nextAttemptAt, id); err != nil {
+		slog.ErrorContext(ctx, "[outboxRepository] MarkOutboxEventFailed", "failed to update outbox event", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"order-service/app/domain"
+	"time"
+)
+
+type rateLimitStore struct {
+	db *sql.DB
+}
+
+func NewRateLimitStore(db *sql.DB) domain.RateLimitStore {
+	return &rateLimitStore{
+		db: db,
+	}
+}
+
+func (s *rateLimitStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	resetAt := time.Now().Truncate(window).Add(window)
+	query := `INSERT INTO rate_limit_counters (key, reset_at, count) VALUES ($1, $2, 1)
+		ON CONFLICT (key, reset_at) DO UPDATE SET count = rate_limit_counters.count + 1
+		RETURNING count`
+	var count int64
+	if err := s.db.QueryRowContext(ctx, query, key, resetAt).Scan(&count); err != nil {
+		slog.ErrorContext(ctx, "[rateLimitStore] Increment", "failed to increment counter", err)
+		return 0, time.Time{}, err
+	}
+	return count, resetAt, nil
+}
+
+func (s *rateLimitStore) Cleanup(ctx context.Context) error {
+	query := `DELETE FROM rate_limit_counters WHERE reset_at <= now()`
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		slog.ErrorContext(ctx, "[rateLimitStore] Cleanup", "failed to delete expired counters", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"order-service/app/domain"
+)
+
+const refundColumns = `id, order_id, quantity, amount, reason, status, stock_action, requested_by_type, requested_by_id,
+	provider_reference, failure_reason, created_at, updated_at, confirmed_at`
+
+type refundRepository struct {
+	db *sql.DB
+}
+
+func NewRefundRepository(db *sql.DB) domain.RefundRepository {
+	return &refundRepository{
+		db: db,
+	}
+}
+
+func scanRefund(row rowScanner, refund *domain.Refund) error {
+	return row.Scan(
+		&refund.ID,
+		&refund.OrderID,
+		&refund.Quantity,
+		&refund.Amount,
+		&refund.Reason,
+		&refund.Status,
+		&refund.StockAction,
+		&refund.RequestedByType,
+		&refund.RequestedByID,
+		&refund.ProviderReference,
+		&refund.FailureReason,
+		&refund.CreatedAt,
+		&refund.UpdatedAt,
+		&refund.ConfirmedAt,
+	)
+}
+
+func (r *refundRepository) CreateRefund(ctx context.Context, refund *domain.Refund, tx *sql.Tx) error {
+	query := `INSERT INTO refunds (order_id, quantity, amount, reason, status, requested_by_type, requested_by_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, now(), now()) RETURNING id, created_at, updated_at`
+	err := tx.QueryRowContext(ctx, query,
+		refund.OrderID,
+		refund.Quantity,
+		refund.Amount,
+		refund.Reason,
+		refund.Status,
+		refund.RequestedByType,
+		refund.RequestedByID,
+	).Scan(&refund.ID, &refund.CreatedAt, &refund.UpdatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "[refundRepository] CreateRefund", "failed to create refund", err)
+		return err
+	}
+	return nil
+}
+
+func (r *refundRepository) GetRefundByIDForUpdate(ctx context.Context, id int64, tx *sql.Tx) (domain.Refund, error) {
+	query := `SELECT ` + refundColumns + ` FROM refunds WHERE id = $1 FOR UPDATE`
+	refund := domain.Refund{}
+	if err := scanRefund(tx.QueryRowContext(ctx, query, id), &refund); err != nil {
+		if err == sql.ErrNoRows {
+			slog.ErrorContext(ctx, "[refundRepository] GetRefundByIDForUpdate", "refund not found", err)
+			return refund, domain.ErrNotFound
+		}
+		slog.ErrorContext(ctx, "[refundRepository] GetRefundByIDForUpdate", "failed to get refund", err)
+		return refund, err
+	}
+	return refund, nil
+}
+
+func (r *refundRepository) GetRefundSummary(ctx context.Context, orderID int64, tx *sql.Tx) (domain.RefundSummary, error) {
+	query := `SELECT
+			COALESCE(SUM(quantity) FILTER (WHERE status IN ($2, $3)), 0),
+			COALESCE(SUM(quantity) FILTER (WHERE status = $3), 0),
+			COALESCE(SUM(amount) FILTER (WHERE status IN ($2, $3)), 0)
+		FROM refunds WHERE order_id = $1`
+	var summary domain.RefundSummary
+	err := tx.QueryRowContext(ctx, query, orderID, domain.RefundStatusPending, domain.RefundStatusSucceeded).
+		Scan(&summary.Requested, &summary.Refunded, &summary.RequestedAmount)
+	if err != nil {
+		slog.ErrorContext(ctx, "[refundRepository] GetRefundSummary", "failed to get refund summary", err)
+		return summary, err
+	}
+	return summary, nil
+}
+
+// UpdateRefundStatus stores the outcome reported by the payment provider.
+func (r *refundRepository) UpdateRefundStatus(ctx context.Context, refund *domain.Refund, tx *sql.Tx) error {
+	query := `UPDATE refunds SET status = $1, stock_action = $2, provider_reference = $3, failure_reason = $4,
+			confirmed_at = now(), updated_at = now()
+		WHERE id = $5 RETURNING updated_at, confirmed_at`
+	err := tx.QueryRowContext(ctx, query, refund.Status, refund.StockAction, refund.ProviderReference, refund.FailureReason, refund.ID).
+		Scan(&refund.UpdatedAt, &refund.ConfirmedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "[refundRepository] UpdateRefundStatus", "failed to update refund", err)
+		return err
+	}
+	return nil
+}
+
+func (r *refundRepository) ListRefundsByOrderID(ctx context.Context, orderID int64) ([]domain.Refund, error) {
+	query := `SELECT ` + refundColumns + ` FROM refunds WHERE order_id = $1 ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		slog.ErrorContext(ctx, "[refundRepository] ListRefundsByOrderID", "failed to list refunds", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := []domain.Refund{}
+	for rows.Next() {
+		refund := domain.Refund{}
+		if err := scanRefund(rows, &refund); err != nil {
+			slog.ErrorContext(ctx, "[refundRepository] ListRefundsByOrderID", "scan error", err)
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+	return refunds, rows.Err()
+}
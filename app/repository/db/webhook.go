@@ -0,0 +1,312 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const webhookDeliveryColumns = `d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+	d.next_attempt_at, d.last_status_code, d.last_error, d.delivered_at, d.created_at, d.updated_at`
+
+type webhookRepository struct {
+	db      *sql.DB
+	typeMap *pgtype.Map
+}
+
+func NewWebhookRepository(db *sql.DB) domain.WebhookRepository {
+	return &webhookRepository{
+		db:      db,
+		typeMap: pgtype.NewMap(),
+	}
+}
+
+func (r *webhookRepository) scanSubscription(row rowScanner, sub *domain.WebhookSubscription) error {
+	var eventTypes []string
+	err := row.Scan(
+		&sub.ID,
+		&sub.URL,
+		r.typeMap.SQLScanner(&eventTypes),
+		&sub.Active,
+		&sub.CreatedAt,
+		&sub.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+	sub.EventTypes = make([]domain.OrderEventType, len(eventTypes))
+	for i, t := range eventTypes {
+		sub.EventTypes[i] = domain.OrderEventType(t)
+	}
+	return nil
+}
+
+func eventTypeStrings(types []domain.OrderEventType) []string {
+	values := make([]string, len(types))
+	for i, t := range types {
+		values[i] = string(t)
+	}
+	return values
+}
+
+func (r *webhookRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return withTransaction(ctx, r.db, fn)
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription, tx *sql.Tx) error {
+	query := `INSERT INTO webhook_subscriptions (url, secret, event_types, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, now(), now()) RETURNING id, created_at, updated_at`
+	err := tx.QueryRowContext(ctx, query, sub.URL, sub.Secret, eventTypeStrings(sub.EventTypes), sub.Active).
+		Scan(&sub.ID, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookRepository] CreateSubscription", "failed to create subscription", err)
+		return err
+	}
+	return nil
+}
+
+func (r *webhookRepository) GetSubscriptionByID(ctx context.Context, id int64) (domain.WebhookSubscription, error) {
+	query := `SELECT id, url, event_types, active, created_at, updated_at FROM webhook_subscriptions WHERE id = $1`
+	sub := domain.WebhookSubscription{}
+	if err := r.scanSubscription(r.db.QueryRowContext(ctx, query, id), &sub); err != nil {
+		if err == sql.ErrNoRows {
+			slog.ErrorContext(ctx, "[webhookRepository] GetSubscriptionByID", "subscription not found", err)
+			return sub, domain.ErrNotFound
+		}
+		slog.ErrorContext(ctx, "[webhookRepository] GetSubscriptionByID", "failed to get subscription", err)
+		return sub, err
+	}
+	return sub, nil
+}
+
+func (r *webhookRepository) GetSubscriptionByIDForUpdate(ctx context.Context, id int64, tx *sql.Tx) (domain.WebhookSubscription, error) {
+	query := `SELECT id, url, event_types, active, created_at, updated_at FROM webhook_subscriptions WHERE id = $1 FOR UPDATE`
+	sub := domain.WebhookSubscription{}
+	if err := r.scanSubscription(tx.QueryRowContext(ctx, query, id), &sub); err != nil {
+		if err == sql.ErrNoRows {
+			return sub, domain.ErrNotFound
+		}
+		slog.ErrorContext(ctx, "[webhookRepository] GetSubscriptionByIDForUpdate", "failed to get subscription", err)
+		return sub, err
+	}
+	return sub, nil
+}
+
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	query := `SELECT id, url, event_types, active, created_at, updated_at FROM webhook_subscriptions ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookRepository] ListSubscriptions", "failed to list subscriptions", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []domain.WebhookSubscription{}
+	for rows.Next() {
+		sub := domain.WebhookSubscription{}
+		if err := r.scanSubscription(rows, &sub); err != nil {
+			slog.ErrorContext(ctx, "[webhookRepository] ListSubscriptions", "scan error", err)
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+// UpdateSubscription keeps the current secret when sub.Secret is empty.
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, sub *domain.WebhookSubscription, tx *sql.Tx) error {
+	query := `UPDATE webhook_subscriptions
+		SET url = $1, event_types = $2, active = $3, secret = COALESCE(NULLIF($4, ''), secret), updated_at = now()
+		WHERE id = $5 RETURNING created_at, updated_at`
+	err := tx.QueryRowContext(ctx, query, sub.URL, eventTypeStrings(sub.EventTypes), sub.Active, sub.Secret, sub.ID).
+		Scan(&sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrNotFound
+		}
+		slog.ErrorContext(ctx, "[webhookRepository] UpdateSubscription", "failed to update subscription", err)
+		return err
+	}
+	return nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id int64, tx *sql.Tx) error {
+	res, err := tx.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookRepository] DeleteSubscription", "failed to delete subscription", err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *webhookRepository) EnqueueDeliveries(ctx context.Context, event domain.OrderEvent, payload []byte, tx *sql.Tx) error {
+	query := `INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at, created_at, updated_at)
+		SELECT id, $1, $2, $3, $4, 0, now(), now(), now()
+		FROM webhook_subscriptions WHERE active AND $2 = ANY(event_types)`
+	_, err := tx.ExecContext(ctx, query, event.ID, string(event.Type), string(payload), domain.WebhookDeliveryPending)
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookRepository] EnqueueDeliveries", "failed to enqueue deliveries", err)
+		return err
+	}
+	return nil
+}
+
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	query := `WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = $1 AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d SET next_attempt_at = now() + make_interval(secs => $3), updated_at = now()
+		FROM due, webhook_subscriptions s
+		WHERE d.id = due.id AND s.id = d.subscription_id
+		RETURNING ` + webhookDeliveryColumns + `, s.url, s.secret`
+	rows, err := r.db.QueryContext(ctx, query, domain.WebhookDeliveryPending, limit, lease.Seconds())
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookRepository] ClaimDueDeliveries", "failed to claim deliveries", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []domain.WebhookDelivery
+	for rows.Next() {
+		delivery := domain.WebhookDelivery{}
+		if err := scanWebhookDelivery(rows, &delivery, &delivery.URL, &delivery.Secret); err != nil {
+			slog.ErrorContext(ctx, "[webhookRepository] ClaimDueDeliveries", "scan error", err)
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func scanWebhookDelivery(row rowScanner, d *domain.WebhookDelivery, extra ...any) error {
+	var payload []byte
+	dest := []any{
+		&d.ID,
+		&d.SubscriptionID,
+		&d.EventID,
+		&d.EventType,
+		&payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastStatusCode,
+		&d.LastError,
+		&d.DeliveredAt,
+		&d.CreatedAt,
+		&d.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	d.Payload = payload
+	return nil
+}
+
+func (r *webhookRepository) MarkDeliverySucceeded(ctx context.Context, id int64, statusCode int) error {
+	query := `UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, last_status_code = $2, last_error = '', delivered_at = now(), updated_at = now()
+		WHERE id = $3`
+	if _, err := r.db.ExecContext(ctx, query, domain.WebhookDeliverySucceeded, statusCode, id); err != nil {
+		slog.ErrorContext(ctx, "[webhookRepository] MarkDeliverySucceeded", "failed to update delivery", err)
+		return err
+	}
+	return nil
+}
+
+func (r *webhookRepository) MarkDeliveryFailed(ctx context.Context, id int64, statusCode int, lastError string, nextAttemptAt *time.Time) error {
+	status := domain.WebhookDeliveryPending
+	if nextAttemptAt == nil {
+		status = domain.WebhookDeliveryFailed
+	}
+	query := `UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, last_status_code = $2, last_error = $3,
+			next_attempt_at = COALESCE($4, next_attempt_at), updated_at = now()
+		WHERE id = $5`
+	if _, err := r.db.ExecContext(ctx, query, status, statusCode, lastError, nextAttemptAt, id); err != nil {
+		slog.ErrorContext(ctx, "[webhookRepository] MarkDeliveryFailed", "failed to update delivery", err)
+		return err
+	}
+	return nil
+}
+
+func (r *webhookRepository) ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error) {
+	conditions := []string{"TRUE"}
+	var args []any
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.SubscriptionID != 0 {
+		add("d.subscription_id = $%d", filter.SubscriptionID)
+	}
+	if filter.Status != "" {
+		add("d.status = $%d", filter.Status)
+	}
+	if filter.EventType != "" {
+		add("d.event_type = $%d", filter.EventType)
+	}
+
+	filter.Normalize()
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query := fmt.Sprintf(`SELECT %s FROM webhook_deliveries d WHERE %s ORDER BY d.id DESC LIMIT $%d OFFSET $%d`,
+		webhookDeliveryColumns, strings.Join(conditions, " AND "), len(args)-1, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookRepository] ListDeliveries", "failed to list deliveries", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []domain.WebhookDelivery{}
+	for rows.Next() {
+		delivery := domain.WebhookDelivery{}
+		if err := scanWebhookDelivery(rows, &delivery); err != nil {
+			slog.ErrorContext(ctx, "[webhookRepository] ListDeliveries", "scan error", err)
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (r *webhookRepository) GetDeliveryByIDForUpdate(ctx context.Context, id int64, tx *sql.Tx) (domain.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries d WHERE d.id = $1 FOR UPDATE`
+	delivery := domain.WebhookDelivery{}
+	if err := scanWebhookDelivery(tx.QueryRowContext(ctx, query, id), &delivery); err != nil {
+		if err == sql.ErrNoRows {
+			return delivery, domain.ErrNotFound
+		}
+		slog.ErrorContext(ctx, "[webhookRepository] GetDeliveryByIDForUpdate", "failed to get delivery", err)
+		return delivery, err
+	}
+	return delivery, nil
+}
+
+// ReplayDelivery queues a delivery again with a fresh set of attempts.
+func (r *webhookRepository) ReplayDelivery(ctx context.Context, id int64, tx *sql.Tx) error {
+	query := `UPDATE webhook_deliveries SET status = $1, attempts = 0, next_attempt_at = now(), updated_at = now()
+		WHERE id = $2`
+	res, err := tx.ExecContext(ctx, query, domain.WebhookDeliveryPending, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookRepository] ReplayDelivery", "failed to replay delivery", err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
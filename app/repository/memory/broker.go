@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"order-service/app/domain"
+	"sync"
+)
+
+var ErrBrokerClosed = errors.New("broker closed")
+
+// subscriptionBuffer is the number of messages a subscriber can lag behind
+// before Publish blocks.
+const subscriptionBuffer = 256
+
+// Broker is an in-process message broker, used as a stand-in for a real
+// broker in tests and local runs. Published messages are kept in memory and
+// handed to the subscribers of their topic; a message the handler fails is
+// delivered again right away with the next attempt number.
+type Broker struct {
+	mu          sync.Mutex
+	messages    []domain.Message
+	subscribers map[string][]chan domain.Message
+	closed      bool
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		subscribers: map[string][]chan domain.Message{},
+	}
+}
+
+func (b *Broker) Publish(ctx context.Context, msg domain.Message) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBrokerClosed
+	}
+	b.messages = append(b.messages, msg)
+	subs := append([]chan domain.Message(nil), b.subscribers[msg.Topic]...)
+	b.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- msg:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Subscribe receives the messages published after it was called.
+func (b *Broker) Subscribe(ctx context.Context, topic string, handler domain.MessageHandler) error {
+	ch := make(chan domain.Message, subscriptionBuffer)
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBrokerClosed
+	}
+	b.subscribers[topic] = append(b.subscribers[topic], ch)
+	b.mu.Unlock()
+
+	defer b.unsubscribe(topic, ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-ch:
+			for attempt := 1; ; attempt++ {
+				if err := handler(ctx, domain.Delivery{Message: msg, Attempt: attempt}); err == nil || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+func (b *Broker) unsubscribe(topic string, ch chan domain.Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.subscribers[topic]
+	for i, sub := range subs {
+		if sub == ch {
+			b.subscribers[topic] = append(subs[:i], subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Messages returns a copy of every message published so far.
+func (b *Broker) Messages() []domain.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]domain.Message(nil), b.messages...)
+}
+
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	return nil
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+	"order-service/app/domain"
+	"sync"
+	"time"
+)
+
+type counter struct {
+	count   int64
+	resetAt time.Time
+}
+
+type rateLimitStore struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+}
+
+func NewRateLimitStore() domain.RateLimitStore {
+	return &rateLimitStore{
+		counters: map[string]*counter{},
+	}
+}
+
+func (s *rateLimitStore) Increment(ctx context.Context, key string, window time.Duration) (int64, time.Time, error) {
+	now := time.Now()
+	windowStart := now.Truncate(window)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.resetAt) {
+		c = &counter{resetAt: windowStart.Add(window)}
+		s.counters[key] = c
+	}
+	c.count++
+	return c.count, c.resetAt, nil
+}
+
+func (s *rateLimitStore) Cleanup(ctx context.Context) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, c := range s.counters {
+		if !now.Before(c.resetAt) {
+			delete(s.counters, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package productrepo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"order-service/app/domain"
+	"order-service/config"
+	"order-service/pkg"
+	"time"
+)
+
+type productRepository struct {
+	httpClient *http.Client
+	cfg        *config.Store
+}
+
+func NewProductRepository(cfg *config.Store) domain.ProductRepository {
+	return &productRepository{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		cfg:        cfg,
+	}
+}
+
+func (r *productRepository) GetProductByID(ctx context.Context, id int64) (domain.Product, error) {
+	url := fmt.Sprintf("%s/internal/product-service/products/%d", r.cfg.Get().ProductService.Host, id)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "[productRepository] GetProductByID", "error http.NewRequestWithContext", err)
+		return domain.Product{}, err
+	}
+
+	pkg.AddRequestHeader(ctx, r.cfg.Get().InternalAuthHeader, httpReq)
+
+	resp, err := r.httpClient.Do(httpReq)
+	if err != nil {
+		slog.ErrorContext(ctx, "[productRepository] GetProductByID", "error httpClient.Do", err)
+		return domain.Product{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		slog.ErrorContext(ctx, "[productRepository] GetProductByID", "product not found", id)
+		return domain.Product{}, fmt.Errorf("%w: product %d", domain.ErrNotFound, id)
+	}
+
+	var product domain.Product
+	if err := pkg.DecodeResponseBody(resp, &product); err != nil {
+		slog.ErrorContext(ctx, "[productRepository] GetProductByID", "error DecodeResponseBody", err)
+		return domain.Product{}, err
+	}
+	return product, nil
+}
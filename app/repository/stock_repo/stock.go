@@ -8,26 +8,50 @@ import (
 	"log/slog"
 	"net/http"
 	"order-service/app/domain"
+	"order-service/config"
 	"order-service/pkg"
 	"time"
 )
 
 type stockRepository struct {
-	httpClient         *http.Client
-	baseURL            string
-	internalAuthHeader string
+	httpClient *http.Client
+	cfg        *config.Store
 }
 
-func NewStockRepository(baseURL string, internalAuthHeader string) domain.StockRepository {
+func NewStockRepository(cfg *config.Store) domain.StockRepository {
 	return &stockRepository{
-		httpClient:         &http.Client{Timeout: 30 * time.Second},
-		baseURL:            baseURL,
-		internalAuthHeader: internalAuthHeader,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		cfg:        cfg,
 	}
 }
 
+func (r *stockRepository) GetAvailableStock(ctx context.Context, productID int64) (int64, error) {
+	url := fmt.Sprintf("%s/internal/warehouse-service/products/%d/available-stock", r.cfg.Get().WarehouseService.Host, productID)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "[stockRepository] GetAvailableStock", "error http.NewRequestWithContext", err)
+		return 0, err
+	}
+
+	pkg.AddRequestHeader(ctx, r.cfg.Get().InternalAuthHeader, httpReq)
+
+	resp, err := r.httpClient.Do(httpReq)
+	if err != nil {
+		slog.ErrorContext(ctx, "[stockRepository] GetAvailableStock", "error httpClient.Do", err)
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var res AvailableProductStockResponse
+	if err := pkg.DecodeResponseBody(resp, &res); err != nil {
+		slog.ErrorContext(ctx, "[stockRepository] GetAvailableStock", "error DecodeResponseBody", err)
+		return 0, err
+	}
+	return res.AvailableStock, nil
+}
+
 func (r *stockRepository) CreateReservedStock(ctx context.Context, req domain.ReservedStockCreateRequest) error {
-	url := fmt.Sprintf("%s/internal/warehouse-service/reserved-stocks", r.baseURL)
+	url := fmt.Sprintf("%s/internal/warehouse-service/reserved-stocks", r.cfg.Get().WarehouseService.Host)
 	reqBody, err := json.Marshal(req)
 	if err != nil {
 		slog.ErrorContext(ctx, "[stockRepository] CreateReservedStock", "error json Marshal", err)
@@ -39,7 +63,7 @@ func (r *stockRepository) CreateReservedStock(ctx context.Context, req domain.Re
 		return err
 	}
 
-	pkg.AddRequestHeader(ctx, r.internalAuthHeader, httpReq)
+	pkg.AddRequestHeader(ctx, r.cfg.Get().InternalAuthHeader, httpReq)
 
 	resp, err := r.httpClient.Do(httpReq)
 	if err != nil {
@@ -58,7 +82,7 @@ func (r *stockRepository) CreateReservedStock(ctx context.Context, req domain.Re
 }
 
 func (r *stockRepository) UpdateReservedStockStatus(ctx context.Context, orderID int64, req domain.ReservedStockUpdateRequest) error {
-	url := fmt.Sprintf("%s/internal/warehouse-service/orders/%d/reserved-stocks/status", r.baseURL, orderID)
+	url := fmt.Sprintf("%s/internal/warehouse-service/orders/%d/reserved-stocks/status", r.cfg.Get().WarehouseService.Host, orderID)
 	reqBody, err := json.Marshal(req)
 	if err != nil {
 		slog.ErrorContext(ctx, "[stockRepository] UpdateReservedStockStatus", "error json Marshal", err)
@@ -70,7 +94,7 @@ func (r *stockRepository) UpdateReservedStockStatus(ctx context.Context, orderID
 		return err
 	}
 
-	pkg.AddRequestHeader(ctx, r.internalAuthHeader, httpReq)
+	pkg.AddRequestHeader(ctx, r.cfg.Get().InternalAuthHeader, httpReq)
 
 	resp, err := r.httpClient.Do(httpReq)
 	if err != nil {
@@ -87,3 +111,51 @@ func (r *stockRepository) UpdateReservedStockStatus(ctx context.Context, orderID
 
 	return nil
 }
+
+// ReleaseReservedStock gives req.Quantity units of the completed reservation
+// of an order back to the available stock.
+func (r *stockRepository) ReleaseReservedStock(ctx context.Context, orderID int64, req domain.ReservedStockReleaseRequest) error {
+	url := fmt.Sprintf("%s/internal/warehouse-service/orders/%d/reserved-stocks/release", r.cfg.Get().WarehouseService.Host, orderID)
+	return r.send(ctx, "ReleaseReservedStock", http.MethodPost, url, req)
+}
+
+func (r *stockRepository) AdjustReservedStock(ctx context.Context, orderID int64, req domain.ReservedStockAdjustRequest) error {
+	url := fmt.Sprintf("%s/internal/warehouse-service/orders/%d/reserved-stocks/quantity", r.cfg.Get().WarehouseService.Host, orderID)
+	return r.send(ctx, "AdjustReservedStock", http.MethodPatch, url, req)
+}
+
+func (r *stockRepository) Restock(ctx context.Context, req domain.RestockRequest) error {
+	url := fmt.Sprintf("%s/internal/warehouse-service/restocks", r.cfg.Get().WarehouseService.Host)
+	return r.send(ctx, "Restock", http.MethodPost, url, req)
+}
+
+// send calls the warehouse with body as JSON and fails unless the response
+// envelope reports success.
+func (r *stockRepository) send(ctx context.Context, method, httpMethod, url string, body any) error {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		slog.ErrorContext(ctx, "[stockRepository] "+method, "error json Marshal", err)
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, httpMethod, url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		slog.ErrorContext(ctx, "[stockRepository] "+method, "error http.NewRequestWithContext", err)
+		return err
+	}
+
+	pkg.AddRequestHeader(ctx, r.cfg.Get().InternalAuthHeader, httpReq)
+
+	resp, err := r.httpClient.Do(httpReq)
+	if err != nil {
+		slog.ErrorContext(ctx, "[stockRepository] "+method, "error httpClient.Do", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	var res any
+	if err := pkg.DecodeResponseBody(resp, &res); err != nil {
+		slog.ErrorContext(ctx, "[stockRepository] "+method, "error DecodeResponseBody", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package taxtable
+
+import (
+	"context"
+	"fmt"
+	"order-service/app/domain"
+	"order-service/pkg"
+	"os"
+	"strings"
+)
+
+// Wildcard matches every region or category in a rule.
+const Wildcard = "*"
+
+// Rule charges RateBasisPoints on the products of Category shipped to
+// Region. Several rules with the same region and category all apply.
+type Rule struct {
+	Region          string `json:"region"`
+	Category        string `json:"category"`
+	Name            string `json:"name"`
+	RateBasisPoints int64  `json:"rate_bps"`
+	Inclusive       bool   `json:"inclusive"`
+}
+
+// File is the tax rules file. DefaultRegion is used for orders without a
+// shipping address.
+type File struct {
+	DefaultRegion string `json:"default_region"`
+	Rules         []Rule `json:"rules"`
+}
+
+type ruleKey struct {
+	region, category string
+}
+
+type tableCalculator struct {
+	defaultRegion string
+	rules         map[ruleKey][]Rule
+}
+
+// NewTableCalculator loads the rules from path. With an empty path no tax
+// is charged.
+func NewTableCalculator(path string) (domain.TaxCalculator, error) {
+	if path == "" {
+		return NewCalculator(File{})
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read tax rules: %w", err)
+	}
+	var file File
+	if err := pkg.StrictJSONUnmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("parse tax rules %s: %w", path, err)
+	}
+	return NewCalculator(file)
+}
+
+// NewCalculator builds a calculator from rules that are already loaded.
+func NewCalculator(file File) (domain.TaxCalculator, error) {
+	calc := &tableCalculator{
+		defaultRegion: strings.ToUpper(file.DefaultRegion),
+		rules:         make(map[ruleKey][]Rule),
+	}
+	for i, rule := range file.Rules {
+		if rule.Region == "" || rule.Category == "" || rule.Name == "" {
+			return nil, fmt.Errorf("tax rule %d: region, category and name are required", i)
+		}
+		if rule.RateBasisPoints < 0 || rule.RateBasisPoints > 10000 {
+			return nil, fmt.Errorf("tax rule %d: rate_bps must be between 0 and 10000", i)
+		}
+		key := ruleKey{region: strings.ToUpper(rule.Region), category: rule.Category}
+		calc.rules[key] = append(calc.rules[key], rule)
+	}
+	return calc, nil
+}
+
+func (c *tableCalculator) Calculate(ctx context.Context, req domain.TaxRequest) ([]domain.TaxLine, error) {
+	region := strings.ToUpper(req.Region)
+	if region == "" {
+		region = c.defaultRegion
+	}
+
+	lines := []domain.TaxLine{}
+	lines = c.appendLines(lines, domain.TaxComponentItems, region, req.Category, req.ItemAmount)
+	lines = c.appendLines(lines, domain.TaxComponentShipping, region, domain.TaxCategoryShipping, req.ShippingAmount)
+	return lines, nil
+}
+
+func (c *tableCalculator) appendLines(lines []domain.TaxLine, component domain.TaxComponent, region, category string, amount int64) []domain.TaxLine {
+	if amount <= 0 {
+		return lines
+	}
+	for _, rule := range c.match(region, category) {
+		lines = append(lines, domain.TaxLine{
+			Component:       component,
+			Name:            rule.Name,
+			Region:          region,
+			Category:        category,
+			RateBasisPoints: rule.RateBasisPoints,
+			Inclusive:       rule.Inclusive,
+			TaxableAmount:   amount,
+			Amount:          taxAmount(amount, rule.RateBasisPoints, rule.Inclusive),
+		})
+	}
+	return lines
+}
+
+// match returns the most specific rules, an exact region beats an exact
+// category.
+func (c *tableCalculator) match(region, category string) []Rule {
+	for _, key := range []ruleKey{
+		{region, category},
+		{region, Wildcard},
+		{Wildcard, category},
+		{Wildcard, Wildcard},
+	} {
+		if rules, ok := c.rules[key]; ok {
+			return rules
+		}
+	}
+	return nil
+}
+
+// taxAmount rounds half up. An inclusive tax is the part of amount that is
+// tax, an exclusive one is charged on top of it.
+func taxAmount(amount, rateBasisPoints int64, inclusive bool) int64 {
+	if inclusive {
+		base := 10000 + rateBasisPoints
+		return (amount*rateBasisPoints + base/2) / base
+	}
+	return (amount*rateBasisPoints + 5000) / 10000
+}
@@ -0,0 +1,121 @@
+package taxtable
+
+import (
+	"context"
+	"order-service/app/domain"
+	"reflect"
+	"testing"
+)
+
+// sampleRules mirrors tax_rules.sample.json.
+var sampleRules = File{
+	DefaultRegion: "ID",
+	Rules: []Rule{
+		{Region: "ID", Category: Wildcard, Name: "PPN", RateBasisPoints: 1100},
+		{Region: "ID", Category: "groceries", Name: "PPN", RateBasisPoints: 0},
+		{Region: "ID", Category: domain.TaxCategoryShipping, Name: "PPN", RateBasisPoints: 1100, Inclusive: true},
+		{Region: "SG", Category: Wildcard, Name: "GST", RateBasisPoints: 900, Inclusive: true},
+	},
+}
+
+func TestCalculate(t *testing.T) {
+	calc, err := NewCalculator(sampleRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		req  domain.TaxRequest
+		want []domain.TaxLine
+	}{
+		{
+			name: "exclusive items and inclusive shipping",
+			req:  domain.TaxRequest{Region: "ID", Category: "electronics", ItemAmount: 100000, ShippingAmount: 10000},
+			want: []domain.TaxLine{
+				{Component: domain.TaxComponentItems, Name: "PPN", Region: "ID", Category: "electronics", RateBasisPoints: 1100, TaxableAmount: 100000, Amount: 11000},
+				{Component: domain.TaxComponentShipping, Name: "PPN", Region: "ID", Category: "shipping", RateBasisPoints: 1100, Inclusive: true, TaxableAmount: 10000, Amount: 991},
+			},
+		},
+		{
+			name: "exact category beats the wildcard",
+			req:  domain.TaxRequest{Region: "ID", Category: "groceries", ItemAmount: 50000},
+			want: []domain.TaxLine{
+				{Component: domain.TaxComponentItems, Name: "PPN", Region: "ID", Category: "groceries", TaxableAmount: 50000},
+			},
+		},
+		{
+			name: "region wildcard applies to shipping",
+			req:  domain.TaxRequest{Region: "sg", Category: "books", ItemAmount: 100000, ShippingAmount: 10000},
+			want: []domain.TaxLine{
+				{Component: domain.TaxComponentItems, Name: "GST", Region: "SG", Category: "books", RateBasisPoints: 900, Inclusive: true, TaxableAmount: 100000, Amount: 8257},
+				{Component: domain.TaxComponentShipping, Name: "GST", Region: "SG", Category: "shipping", RateBasisPoints: 900, Inclusive: true, TaxableAmount: 10000, Amount: 826},
+			},
+		},
+		{
+			name: "pickup uses the default region",
+			req:  domain.TaxRequest{Category: "electronics", ItemAmount: 1000},
+			want: []domain.TaxLine{
+				{Component: domain.TaxComponentItems, Name: "PPN", Region: "ID", Category: "electronics", RateBasisPoints: 1100, TaxableAmount: 1000, Amount: 110},
+			},
+		},
+		{
+			name: "region without rules",
+			req:  domain.TaxRequest{Region: "US", Category: "electronics", ItemAmount: 1000, ShippingAmount: 500},
+			want: []domain.TaxLine{},
+		},
+		{
+			name: "fully discounted order",
+			req:  domain.TaxRequest{Region: "ID", Category: "electronics"},
+			want: []domain.TaxLine{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calc.Calculate(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Calculate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCalculatorRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{name: "missing name", rule: Rule{Region: "ID", Category: Wildcard, RateBasisPoints: 1100}},
+		{name: "missing region", rule: Rule{Category: Wildcard, Name: "PPN", RateBasisPoints: 1100}},
+		{name: "negative rate", rule: Rule{Region: "ID", Category: Wildcard, Name: "PPN", RateBasisPoints: -1}},
+		{name: "rate above 100%", rule: Rule{Region: "ID", Category: Wildcard, Name: "PPN", RateBasisPoints: 10001}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewCalculator(File{Rules: []Rule{tt.rule}}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestTaxAmountRoundsHalfUp(t *testing.T) {
+	tests := []struct {
+		amount, rate int64
+		inclusive    bool
+		want         int64
+	}{
+		{amount: 10, rate: 500, want: 1},
+		{amount: 9, rate: 500, want: 0},
+		{amount: 11100, rate: 1100, inclusive: true, want: 1100},
+		{amount: 105, rate: 500, inclusive: true, want: 5},
+	}
+	for _, tt := range tests {
+		if got := taxAmount(tt.amount, tt.rate, tt.inclusive); got != tt.want {
+			t.Errorf("taxAmount(%d, %d, %v) = %d, want %d", tt.amount, tt.rate, tt.inclusive, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package webhookclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"order-service/app/domain"
+	"order-service/config"
+	"time"
+)
+
+type webhookSender struct {
+	httpClient *http.Client
+	cfg        *config.Store
+}
+
+func NewWebhookSender(cfg *config.Store) domain.WebhookSender {
+	return &webhookSender{
+		// redirects are not followed, the subscriber must register the final URL
+		httpClient: &http.Client{
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg: cfg,
+	}
+}
+
+// Send posts body to url. Any status outside 2xx is returned as an error
+// together with the status code.
+func (s *webhookSender) Send(ctx context.Context, url string, header http.Header, body []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.Get().Webhook.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookSender] Send", "error http.NewRequestWithContext", err)
+		return 0, err
+	}
+	for key, values := range header {
+		for _, v := range values {
+			httpReq.Header.Add(key, v)
+		}
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// drain a bounded part of the body so the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+)
+
+func (u *orderUsecase) AdminSearchOrders(ctx context.Context, filter domain.OrderFilter) ([]domain.Order, error) {
+	orders, err := u.orderRepository.SearchOrders(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] AdminSearchOrders", "failed to search orders", err)
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (u *orderUsecase) AdminGetOrderByID(ctx context.Context, id int64) (domain.Order, error) {
+	order, err := u.orderRepository.GetOrderByID(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] AdminGetOrderByID", "failed to get order by ID", err)
+		return domain.Order{}, err
+	}
+	return order, nil
+}
+
+// ForceCancelOrder cancels an unpaid order and its stock reservation. An
+// order that was paid for is refused, cancelled is final and could not be
+// refunded anymore, so it has to go through a refund instead.
+func (u *orderUsecase) ForceCancelOrder(ctx context.Context, adminID, id int64, req domain.AdminOrderActionRequest) (domain.Order, error) {
+	return u.forceOrderStatus(ctx, adminID, id, domain.OrderStatusCancelled, domain.AuditActionOrderForceCancel, req.Reason,
+		func(order domain.Order) error {
+			if order.Status.CanBeRefunded() {
+				slog.ErrorContext(ctx, "[orderUsecase] ForceCancelOrder", "order was paid", string(order.Status))
+				return fmt.Errorf("%w: order is %s, request a refund instead", domain.ErrConflict, order.Status)
+			}
+			return nil
+		},
+		func(order domain.Order) string {
+			if order.Status == domain.OrderStatusWaitingPayment {
+				return domain.ReservedStockStatusCancelled
+			}
+			return ""
+		})
+}
+
+// ForceCompleteOrder completes an order in any non final status, completing
+// the stock reservation if the order was still unpaid.
+func (u *orderUsecase) ForceCompleteOrder(ctx context.Context, adminID, id int64, req domain.AdminOrderActionRequest) (domain.Order, error) {
+	return u.forceOrderStatus(ctx, adminID, id, domain.OrderStatusCompleted, domain.AuditActionOrderForceComplete, req.Reason, nil,
+		func(order domain.Order) string {
+			if order.Status == domain.OrderStatusWaitingPayment {
+				return domain.ReservedStockStatusCompleted
+			}
+			return ""
+		})
+}
+
+// forceOrderStatus applies an admin override. check, when set, may refuse the
+// locked order. stockStatus returns the reserved stock status to send to the
+// warehouse, or an empty string to leave it as is.
+func (u *orderUsecase) forceOrderStatus(ctx context.Context, adminID, id int64, next domain.OrderStatus, action domain.AuditAction, reason string,
+	check func(order domain.Order) error, stockStatus func(order domain.Order) string) (domain.Order, error) {
+	err := u.orderRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		order, err := u.orderRepository.GetOrderByIDForUpdate(ctx, id, tx)
+		if err != nil {
+			return err
+		}
+
+		if err := checkExpectedVersion(ctx, order); err != nil {
+			return err
+		}
+
+		if order.Status.IsFinal() {
+			slog.ErrorContext(ctx, "[orderUsecase] forceOrderStatus", "order is final", string(order.Status))
+			return fmt.Errorf("%w: order is %s", domain.ErrConflict, order.Status)
+		}
+		if check != nil {
+			if err := check(order); err != nil {
+				return err
+			}
+		}
+
+		if err := u.orderRepository.UpdateStatusOrder(ctx, order.ID, order.Version, string(next), tx); err != nil {
+			return err
+		}
+
+		if status := stockStatus(order); status != "" {
+			reservedStockReq := domain.ReservedStockUpdateRequest{Status: status}
+			if err := u.stockRepository.UpdateReservedStockStatus(ctx, order.ID, reservedStockReq); err != nil {
+				slog.ErrorContext(ctx, "[orderUsecase] forceOrderStatus", "failed to update reserved stock status", err)
+				return err
+			}
+		}
+
+		history := domain.OrderHistory{
+			OrderID:    order.ID,
+			FromStatus: order.Status,
+			ToStatus:   next,
+			ActorType:  domain.ActorTypeAdmin,
+			ActorID:    adminID,
+			Note:       reason,
+		}
+		if err := u.orderRepository.CreateOrderHistory(ctx, &history, tx); err != nil {
+			return err
+		}
+
+		actor := domain.Actor{Type: domain.ActorTypeAdmin, ID: adminID}
+		if err := u.recordAudit(ctx, tx, actor, action, order.ID, &order); err != nil {
+			return err
+		}
+		if next == domain.OrderStatusCancelled {
+			if err := u.couponRepository.ReleaseRedemption(ctx, order.ID, tx); err != nil {
+				return err
+			}
+			return u.emitEvent(ctx, tx, domain.OrderEventCancelled, order.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] forceOrderStatus", "transaction", err)
+		return domain.Order{}, err
+	}
+
+	slog.InfoContext(ctx, "[orderUsecase] success forceOrderStatus", "order_id", id, "status", next, "admin_id", adminID)
+	return u.orderRepository.GetOrderByID(ctx, id)
+}
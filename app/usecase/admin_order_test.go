@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"order-service/app/domain"
+	"testing"
+)
+
+func (r *fakeOrderRepository) GetOrderByID(ctx context.Context, id int64) (domain.Order, error) {
+	return r.GetOrderByIDForUpdate(ctx, id, nil)
+}
+
+func (r *fakeOrderRepository) UpdateStatusOrder(ctx context.Context, id, version int64, status string, tx *sql.Tx) error {
+	order := r.orders[id]
+	order.Status = domain.OrderStatus(status)
+	r.orders[id] = order
+	return nil
+}
+
+func (r *fakeStockRepository) UpdateReservedStockStatus(ctx context.Context, orderID int64, req domain.ReservedStockUpdateRequest) error {
+	r.statuses = append(r.statuses, req.Status)
+	return nil
+}
+
+type fakeCouponRepository struct {
+	domain.CouponRepository
+	released []int64
+}
+
+func (r *fakeCouponRepository) ReleaseRedemption(ctx context.Context, orderID int64, tx *sql.Tx) error {
+	r.released = append(r.released, orderID)
+	return nil
+}
+
+func TestForceCancelOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      domain.OrderStatus
+		wantErr     error
+		wantStatus  domain.OrderStatus
+		wantStock   []string
+		wantEvents  int
+		wantRelease int
+	}{
+		{
+			name:        "unpaid order",
+			status:      domain.OrderStatusWaitingPayment,
+			wantStatus:  domain.OrderStatusCancelled,
+			wantStock:   []string{domain.ReservedStockStatusCancelled},
+			wantEvents:  1,
+			wantRelease: 1,
+		},
+		{name: "paid order must be refunded", status: domain.OrderStatusPaid, wantErr: domain.ErrConflict, wantStatus: domain.OrderStatusPaid},
+		{name: "accepted order must be refunded", status: domain.OrderStatusAccepted, wantErr: domain.ErrConflict, wantStatus: domain.OrderStatusAccepted},
+		{name: "final order", status: domain.OrderStatusRefunded, wantErr: domain.ErrConflict, wantStatus: domain.OrderStatusRefunded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := &fakeOrderRepository{orders: map[int64]domain.Order{1: {ID: 1, Status: tt.status, Quantity: 2}}}
+			u, webhooks, _ := newTestOrderUsecase(orders)
+			stock := &fakeStockRepository{}
+			coupons := &fakeCouponRepository{}
+			u.stockRepository, u.couponRepository = stock, coupons
+
+			_, err := u.ForceCancelOrder(context.Background(), 9, 1, domain.AdminOrderActionRequest{Reason: "fraud report"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := orders.orders[1].Status; got != tt.wantStatus {
+				t.Errorf("status = %s, want %s", got, tt.wantStatus)
+			}
+			if len(stock.statuses) != len(tt.wantStock) || (len(tt.wantStock) > 0 && stock.statuses[0] != tt.wantStock[0]) {
+				t.Errorf("reserved stock statuses = %v, want %v", stock.statuses, tt.wantStock)
+			}
+			if len(webhooks.events) != tt.wantEvents || len(coupons.released) != tt.wantRelease {
+				t.Errorf("events = %d, coupon releases = %d, want %d and %d", len(webhooks.events), len(coupons.released), tt.wantEvents, tt.wantRelease)
+			}
+		})
+	}
+}
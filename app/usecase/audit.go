@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/pkg/ctxutil"
+)
+
+type auditUsecase struct {
+	auditRepository domain.AuditRepository
+}
+
+func NewAuditUsecase(auditRepository domain.AuditRepository) domain.AuditUsecase {
+	return &auditUsecase{
+		auditRepository: auditRepository,
+	}
+}
+
+func (u *auditUsecase) SearchAuditLogs(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditLog, error) {
+	logs, err := u.auditRepository.SearchAuditLogs(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "[auditUsecase] SearchAuditLogs", "failed to search audit logs", err)
+		return nil, err
+	}
+	return logs, nil
+}
+
+// recordAudit writes the audit log of a change to an order within tx, so the
+// entry is committed or rolled back together with the change. The after
+// snapshot is read back through tx; before is nil for creations.
+func (u *orderUsecase) recordAudit(ctx context.Context, tx *sql.Tx, actor domain.Actor, action domain.AuditAction, orderID int64, before *domain.Order) error {
+	after, err := u.orderRepository.GetOrderByIDForUpdate(ctx, orderID, tx)
+	if err != nil {
+		return err
+	}
+
+	log := domain.AuditLog{
+		ActorType:    actor.Type,
+		ActorID:      actor.ID,
+		Action:       action,
+		ResourceType: domain.AuditResourceOrder,
+		ResourceID:   orderID,
+		OrderID:      &orderID,
+	}
+	var beforeSnapshot any
+	if before != nil {
+		beforeSnapshot = before
+	}
+	if err := writeAuditLog(ctx, tx, u.auditRepository, log, beforeSnapshot, after); err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] recordAudit", "failed to create audit log", err)
+		return err
+	}
+	return nil
+}
+
+// recordStatusChange records a status transition of before to next made
+// within tx: the order history, the audit log and the outbox event. A
+// cancellation also releases the coupon redemption of the order.
+func (u *orderUsecase) recordStatusChange(ctx context.Context, tx *sql.Tx, before domain.Order, next domain.OrderStatus,
+	actorType domain.ActorType, action domain.AuditAction, note string, eventType domain.OrderEventType) error {
+	// a cancelled order no longer counts against the coupon limits
+	if next == domain.OrderStatusCancelled {
+		if err := u.couponRepository.ReleaseRedemption(ctx, before.ID, tx); err != nil {
+			return err
+		}
+	}
+
+	history := domain.OrderHistory{
+		OrderID:    before.ID,
+		FromStatus: before.Status,
+		ToStatus:   next,
+		ActorType:  actorType,
+		Note:       note,
+	}
+	if err := u.orderRepository.CreateOrderHistory(ctx, &history, tx); err != nil {
+		return err
+	}
+
+	actor := domain.Actor{Type: actorType}
+	if err := u.recordAudit(ctx, tx, actor, action, before.ID, &before); err != nil {
+		return err
+	}
+	return u.emitEvent(ctx, tx, eventType, before.ID)
+}
+
+// writeAuditLog stores log with the before and after snapshots of the
+// resource within tx. A nil snapshot is stored as null.
+func writeAuditLog(ctx context.Context, tx *sql.Tx, auditRepository domain.AuditRepository, log domain.AuditLog, before, after any) error {
+	log.RequestID = ctxutil.GetRequestID(ctx)
+	log.SourceIP = ctxutil.GetSourceIP(ctx)
+
+	var err error
+	if before != nil {
+		if log.Before, err = json.Marshal(before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if log.After, err = json.Marshal(after); err != nil {
+			return err
+		}
+	}
+	return auditRepository.CreateAuditLog(ctx, &log, tx)
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+)
+
+func (u *orderUsecase) HandlePaymentEvent(ctx context.Context, msg domain.Message, status domain.OrderStatus, payload domain.PaymentEventPayload) error {
+	if _, _, err := paymentResult(status); err != nil {
+		return err
+	}
+
+	note := payload.Reason
+	if note == "" && payload.PaymentID != "" {
+		note = "payment " + payload.PaymentID
+	}
+
+	return u.handleOrderMessage(ctx, msg, payload.OrderID, func(ctx context.Context, tx *sql.Tx, order domain.Order) error {
+		return u.applyPayment(ctx, tx, order, status, domain.AuditActionPaymentEvent, payload.PaymentID, note)
+	})
+}
+
+func (u *orderUsecase) HandleStockReleased(ctx context.Context, msg domain.Message, payload domain.StockReleasedPayload) error {
+	return u.handleOrderMessage(ctx, msg, payload.OrderID, func(ctx context.Context, tx *sql.Tx, order domain.Order) error {
+		switch order.Status {
+		case domain.OrderStatusCancelled:
+			return nil
+		case domain.OrderStatusWaitingPayment:
+		default:
+			// the stock of a paid order is gone, this needs a person to look at it
+			return fmt.Errorf("%w: stock released for %s order", domain.ErrConflict, order.Status)
+		}
+
+		// the reservation is gone already, so the warehouse is not called back
+		if err := u.orderRepository.UpdateStatusOrder(ctx, order.ID, order.Version, string(domain.OrderStatusCancelled), tx); err != nil {
+			return err
+		}
+		return u.recordStatusChange(ctx, tx, order, domain.OrderStatusCancelled, domain.ActorTypeSystem, domain.AuditActionStockReleased,
+			payload.Reason, domain.OrderEventCancelled)
+	})
+}
+
+// handleOrderMessage runs apply on the locked order in one transaction with
+// the processed message record, so a message is applied exactly once even
+// when the broker delivers it again.
+func (u *orderUsecase) handleOrderMessage(ctx context.Context, msg domain.Message, orderID int64,
+	apply func(ctx context.Context, tx *sql.Tx, order domain.Order) error) error {
+	err := u.orderRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		first, err := u.inboxRepository.MarkMessageProcessed(ctx, msg, tx)
+		if err != nil {
+			return err
+		}
+		if !first {
+			slog.InfoContext(ctx, "[orderUsecase] handleOrderMessage", "duplicate message", msg.ID, "topic", msg.Topic)
+			return nil
+		}
+
+		order, err := u.orderRepository.GetOrderByIDForUpdate(ctx, orderID, tx)
+		if err != nil {
+			return err
+		}
+		return apply(ctx, tx, order)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] handleOrderMessage", "transaction", err, "message_id", msg.ID, "topic", msg.Topic)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"maps"
+	"order-service/app/domain"
+	"testing"
+)
+
+type fakeInboxRepository struct {
+	domain.InboxRepository
+	processed map[string]bool
+}
+
+func (r *fakeInboxRepository) MarkMessageProcessed(ctx context.Context, msg domain.Message, tx *sql.Tx) (bool, error) {
+	if r.processed[msg.ID] {
+		return false, nil
+	}
+	r.processed[msg.ID] = true
+	return true, nil
+}
+
+// fakeOrderRepository runs transactions without a database. A failed
+// transaction rolls back the processed messages of the inbox, if any.
+type fakeOrderRepository struct {
+	domain.OrderRepository
+	inbox  *fakeInboxRepository
+	orders map[int64]domain.Order
+}
+
+func (r *fakeOrderRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	if r.inbox == nil {
+		return fn(ctx, nil)
+	}
+	processed := maps.Clone(r.inbox.processed)
+	if err := fn(ctx, nil); err != nil {
+		r.inbox.processed = processed
+		return err
+	}
+	return nil
+}
+
+func (r *fakeOrderRepository) GetOrderByIDForUpdate(ctx context.Context, id int64, tx *sql.Tx) (domain.Order, error) {
+	order, ok := r.orders[id]
+	if !ok {
+		return domain.Order{}, domain.ErrNotFound
+	}
+	return order, nil
+}
+
+func TestHandleOrderMessageDeduplicates(t *testing.T) {
+	errTransient := errors.New("warehouse unavailable")
+
+	tests := []struct {
+		name        string
+		messageIDs  []string
+		failOnCall  int
+		wantCalls   int
+		wantApplied int
+	}{
+		{name: "redelivery is skipped", messageIDs: []string{"m1", "m1"}, wantCalls: 1, wantApplied: 1},
+		{name: "distinct messages", messageIDs: []string{"m1", "m2"}, wantCalls: 2, wantApplied: 2},
+		{name: "failed message is applied on redelivery", messageIDs: []string{"m1", "m1", "m1"}, failOnCall: 1, wantCalls: 2, wantApplied: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inbox := &fakeInboxRepository{processed: map[string]bool{}}
+			u := &orderUsecase{
+				orderRepository: &fakeOrderRepository{inbox: inbox, orders: map[int64]domain.Order{1: {ID: 1}}},
+				inboxRepository: inbox,
+			}
+
+			calls, applied := 0, 0
+			apply := func(ctx context.Context, tx *sql.Tx, order domain.Order) error {
+				calls++
+				if calls == tt.failOnCall {
+					return errTransient
+				}
+				applied++
+				return nil
+			}
+			for _, id := range tt.messageIDs {
+				msg := domain.Message{ID: id, Topic: "payment-service.payment.succeeded.v1"}
+				if err := u.handleOrderMessage(context.Background(), msg, 1, apply); err != nil && !errors.Is(err, errTransient) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if calls != tt.wantCalls || applied != tt.wantApplied {
+				t.Errorf("calls = %d, applied = %d, want %d and %d", calls, applied, tt.wantCalls, tt.wantApplied)
+			}
+		})
+	}
+}
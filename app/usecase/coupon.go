@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"time"
+)
+
+type couponUsecase struct {
+	couponRepository domain.CouponRepository
+	auditRepository  domain.AuditRepository
+}
+
+func NewCouponUsecase(couponRepository domain.CouponRepository, auditRepository domain.AuditRepository) domain.CouponUsecase {
+	return &couponUsecase{
+		couponRepository: couponRepository,
+		auditRepository:  auditRepository,
+	}
+}
+
+func couponFromRequest(req domain.CouponRequest) domain.Coupon {
+	coupon := domain.Coupon{
+		Code:         domain.NormalizeCouponCode(req.Code),
+		Type:         domain.CouponType(req.Type),
+		Value:        req.Value,
+		MaxDiscount:  req.MaxDiscount,
+		MinSubtotal:  req.MinSubtotal,
+		StartsAt:     req.StartsAt,
+		EndsAt:       req.EndsAt,
+		UsageLimit:   req.UsageLimit,
+		PerUserLimit: req.PerUserLimit,
+		ProductIDs:   req.ProductIDs,
+		Active:       req.Active == nil || *req.Active,
+	}
+	if coupon.Type == domain.CouponTypeFreeShipping {
+		coupon.Value = 0
+	}
+	if coupon.ProductIDs == nil {
+		coupon.ProductIDs = []int64{}
+	}
+	return coupon
+}
+
+func (u *couponUsecase) CreateCoupon(ctx context.Context, adminID int64, req domain.CouponRequest) (domain.Coupon, error) {
+	coupon := couponFromRequest(req)
+	err := u.couponRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := u.couponRepository.CreateCoupon(ctx, &coupon, tx); err != nil {
+			return err
+		}
+		return u.recordAudit(ctx, tx, adminID, domain.AuditActionCouponCreate, coupon.ID, nil)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[couponUsecase] CreateCoupon", "failed to create coupon", err)
+		return domain.Coupon{}, err
+	}
+
+	slog.InfoContext(ctx, "[couponUsecase] success CreateCoupon", "coupon_id", coupon.ID, "code", coupon.Code)
+	return coupon, nil
+}
+
+func (u *couponUsecase) GetCouponByID(ctx context.Context, id int64) (domain.Coupon, error) {
+	coupon, err := u.couponRepository.GetCouponByID(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "[couponUsecase] GetCouponByID", "failed to get coupon", err)
+		return domain.Coupon{}, err
+	}
+	return coupon, nil
+}
+
+func (u *couponUsecase) ListCoupons(ctx context.Context, filter domain.CouponFilter) ([]domain.Coupon, error) {
+	coupons, err := u.couponRepository.ListCoupons(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "[couponUsecase] ListCoupons", "failed to list coupons", err)
+		return nil, err
+	}
+	return coupons, nil
+}
+
+// UpdateCoupon replaces the coupon settings. Orders keep the code and the
+// discount they were created with.
+func (u *couponUsecase) UpdateCoupon(ctx context.Context, adminID, id int64, req domain.CouponRequest) (domain.Coupon, error) {
+	coupon := couponFromRequest(req)
+	coupon.ID = id
+	err := u.couponRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := u.couponRepository.GetCouponByIDForUpdate(ctx, id, tx)
+		if err != nil {
+			return err
+		}
+		if err := u.couponRepository.UpdateCoupon(ctx, &coupon, tx); err != nil {
+			return err
+		}
+		return u.recordAudit(ctx, tx, adminID, domain.AuditActionCouponUpdate, id, &before)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[couponUsecase] UpdateCoupon", "failed to update coupon", err)
+		return domain.Coupon{}, err
+	}
+	return u.couponRepository.GetCouponByID(ctx, id)
+}
+
+// recordAudit writes the audit log of an admin change to a coupon within tx.
+// The after snapshot is read back through tx; before is nil for creations.
+func (u *couponUsecase) recordAudit(ctx context.Context, tx *sql.Tx, adminID int64, action domain.AuditAction, couponID int64, before *domain.Coupon) error {
+	after, err := u.couponRepository.GetCouponByIDForUpdate(ctx, couponID, tx)
+	if err != nil {
+		return err
+	}
+
+	log := domain.AuditLog{
+		ActorType:    domain.ActorTypeAdmin,
+		ActorID:      adminID,
+		Action:       action,
+		ResourceType: domain.AuditResourceCoupon,
+		ResourceID:   couponID,
+	}
+	var beforeSnapshot any
+	if before != nil {
+		beforeSnapshot = before
+	}
+	if err := writeAuditLog(ctx, tx, u.auditRepository, log, beforeSnapshot, after); err != nil {
+		slog.ErrorContext(ctx, "[couponUsecase] recordAudit", "failed to create audit log", err)
+		return err
+	}
+	return nil
+}
+
+// applyCoupon checks the coupon against the priced order and takes its
+// discount off the total. The coupon stays locked until tx ends, so the
+// usage limits hold under concurrent orders. The redemption is left in
+// order.Pricing.Coupon and stored once the order has an ID.
+func (u *orderUsecase) applyCoupon(ctx context.Context, tx *sql.Tx, order *domain.Order, code string) error {
+	coupon, err := u.couponRepository.GetCouponByCodeForUpdate(ctx, domain.NormalizeCouponCode(code), tx)
+	if errors.Is(err, domain.ErrNotFound) {
+		slog.WarnContext(ctx, "[orderUsecase] applyCoupon", "unknown coupon", code)
+		return domain.ErrCouponInvalid
+	}
+	if err != nil {
+		return err
+	}
+
+	if !coupon.ValidAt(time.Now()) {
+		slog.WarnContext(ctx, "[orderUsecase] applyCoupon", "coupon not valid now", coupon.Code)
+		return domain.ErrCouponInvalid
+	}
+	if !coupon.AppliesTo(order.ProductID) {
+		return fmt.Errorf("%w: product %d is not eligible", domain.ErrCouponNotEligible, order.ProductID)
+	}
+	if order.Pricing.Subtotal < coupon.MinSubtotal {
+		return fmt.Errorf("%w: subtotal must be at least %d", domain.ErrCouponNotEligible, coupon.MinSubtotal)
+	}
+	if err := u.checkCouponUsage(ctx, tx, coupon, order.UserID); err != nil {
+		return err
+	}
+
+	item, shipping := coupon.Discount(order.Pricing.Subtotal, order.Pricing.ShippingFee)
+	if item+shipping == 0 {
+		return fmt.Errorf("%w: nothing to discount", domain.ErrCouponNotEligible)
+	}
+
+	redemption := domain.CouponRedemption{
+		CouponID:         coupon.ID,
+		UserID:           order.UserID,
+		Code:             coupon.Code,
+		Type:             coupon.Type,
+		ItemDiscount:     item,
+		ShippingDiscount: shipping,
+		Status:           domain.CouponRedemptionRedeemed,
+	}
+	order.Pricing.Discount = item + shipping
+	order.Pricing.Total -= order.Pricing.Discount
+	order.Pricing.Coupon = &redemption
+	return nil
+}
+
+// checkCouponUsage checks the usage limits of a locked coupon before another
+// redemption by userID.
+func (u *orderUsecase) checkCouponUsage(ctx context.Context, tx *sql.Tx, coupon domain.Coupon, userID int64) error {
+	if coupon.UsageLimit > 0 && coupon.Redemptions >= coupon.UsageLimit {
+		slog.WarnContext(ctx, "[orderUsecase] checkCouponUsage", "usage limit", coupon.Code)
+		return domain.ErrCouponUsageLimit
+	}
+	if coupon.PerUserLimit > 0 {
+		used, err := u.couponRepository.CountUserRedemptions(ctx, coupon.ID, userID, tx)
+		if err != nil {
+			return err
+		}
+		if used >= coupon.PerUserLimit {
+			slog.WarnContext(ctx, "[orderUsecase] checkCouponUsage", "per user limit", coupon.Code)
+			return fmt.Errorf("%w: at most %d uses per user", domain.ErrCouponUsageLimit, coupon.PerUserLimit)
+		}
+	}
+	return nil
+}
+
+// redeemCouponAgain restores the coupon redemption an order released when it
+// expired, as long as the usage limits still allow it. The discount is the
+// one the order was priced with, since that is what was paid.
+func (u *orderUsecase) redeemCouponAgain(ctx context.Context, tx *sql.Tx, order domain.Order) error {
+	redemption := order.Pricing.Coupon
+	if redemption == nil || redemption.Status != domain.CouponRedemptionReleased {
+		return nil
+	}
+
+	coupon, err := u.couponRepository.GetCouponByIDForUpdate(ctx, redemption.CouponID, tx)
+	if err != nil {
+		return err
+	}
+	if err := u.checkCouponUsage(ctx, tx, coupon, order.UserID); err != nil {
+		return err
+	}
+	return u.couponRepository.RestoreRedemption(ctx, order.ID, tx)
+}
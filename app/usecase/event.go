@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/config"
+	"strconv"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+)
+
+// maxRelayBackoff caps the retry delay of an event the broker rejected.
+const maxRelayBackoff = 5 * time.Minute
+
+// relayPublishTimeout bounds the wait for the broker to accept one event.
+const relayPublishTimeout = 5 * time.Second
+
+type eventRelayUsecase struct {
+	outboxRepository domain.OutboxRepository
+	publisher        domain.EventPublisher
+	cfg              *config.Store
+}
+
+func NewEventRelayUsecase(outboxRepository domain.OutboxRepository, publisher domain.EventPublisher, cfg *config.Store) domain.EventRelayUsecase {
+	return &eventRelayUsecase{
+		outboxRepository: outboxRepository,
+		publisher:        publisher,
+		cfg:              cfg,
+	}
+}
+
+// PublishPendingEvents relays due outbox events to the broker, oldest first.
+// An event is marked published only after the broker accepted it, so events
+// are delivered at least once.
+func (u *eventRelayUsecase) PublishPendingEvents(ctx context.Context) {
+	batchSize := u.cfg.Get().Event.RelayBatchSize
+	// events are published one after another, the lease covers a batch in
+	// which every publish times out, so no other replica claims them meanwhile
+	lease := claimLease(batchSize, relayPublishTimeout)
+	leaseEnd := time.Now().Add(lease)
+
+	events, err := u.outboxRepository.ClaimPendingOutboxEvents(ctx, batchSize, lease)
+	if err != nil {
+		slog.ErrorContext(ctx, "[eventRelayUsecase] PublishPendingEvents", "failed to claim outbox events", err)
+		return
+	}
+
+	for i, event := range events {
+		if time.Until(leaseEnd) < relayPublishTimeout {
+			slog.WarnContext(ctx, "[eventRelayUsecase] PublishPendingEvents", "claim lease ending, events left", len(events)-i)
+			return
+		}
+		if err := u.publish(ctx, event.Message); err != nil {
+			delay := min(time.Second<<min(event.Attempts, 16), maxRelayBackoff)
+			slog.WarnContext(ctx, "[eventRelayUsecase] PublishPendingEvents", "outbox_id", event.ID, "attempts", event.Attempts+1, "error", err)
+			if err := u.outboxRepository.MarkOutboxEventFailed(ctx, event.ID, err.Error(), time.Now().Add(delay)); err != nil {
+				slog.ErrorContext(ctx, "[eventRelayUsecase] PublishPendingEvents", "failed to mark outbox event failed", err)
+			}
+			continue
+		}
+		if err := u.outboxRepository.MarkOutboxEventPublished(ctx, event.ID); err != nil {
+			slog.ErrorContext(ctx, "[eventRelayUsecase] PublishPendingEvents", "failed to mark outbox event published", err)
+		}
+	}
+}
+
+func (u *eventRelayUsecase) publish(ctx context.Context, msg domain.Message) error {
+	ctx, cancel := context.WithTimeout(ctx, relayPublishTimeout)
+	defer cancel()
+	return u.publisher.Publish(ctx, msg)
+}
+
+// emitEvent records an order event within tx: it is queued for the webhook
+// subscribers and written to the outbox for the message broker, so it is only
+// sent if the change that produced it is committed.
+func (u *orderUsecase) emitEvent(ctx context.Context, tx *sql.Tx, eventType domain.OrderEventType, orderID int64) error {
+	return u.emitRefundEvent(ctx, tx, eventType, orderID, nil)
+}
+
+// emitRefundEvent is emitEvent with the refund the event is about.
+func (u *orderUsecase) emitRefundEvent(ctx context.Context, tx *sql.Tx, eventType domain.OrderEventType, orderID int64, refund *domain.Refund) error {
+	order, err := u.orderRepository.GetOrderByIDForUpdate(ctx, orderID, tx)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+	event := domain.OrderEvent{
+		ID:         id.String(),
+		Type:       eventType,
+		Version:    domain.OrderEventVersion,
+		OccurredAt: time.Now().UTC(),
+		Data:       domain.NewOrderEventData(order),
+		Refund:     refund,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if err := u.webhookRepository.EnqueueDeliveries(ctx, event, payload, tx); err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] emitEvent", "failed to enqueue webhook deliveries", err)
+		return err
+	}
+
+	cfg := u.cfg.Get().Event
+	if cfg.Publisher == "none" {
+		return nil
+	}
+	outboxEvent := domain.OutboxEvent{
+		Message: domain.Message{
+			ID:      event.ID,
+			Topic:   cfg.Topic(string(eventType), event.Version),
+			Key:     strconv.FormatInt(order.ID, 10),
+			Payload: payload,
+		},
+	}
+	if err := u.outboxRepository.CreateOutboxEvent(ctx, &outboxEvent, tx); err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] emitEvent", "failed to create outbox event", err)
+		return err
+	}
+	return nil
+}
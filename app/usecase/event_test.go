@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"order-service/app/domain"
+	"order-service/app/repository/memory"
+	"order-service/config"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeOutboxRepository struct {
+	domain.OutboxRepository
+	created   []domain.OutboxEvent
+	pending   []domain.OutboxEvent
+	lease     time.Duration
+	published []int64
+	failed    map[int64]time.Time
+}
+
+func (r *fakeOutboxRepository) CreateOutboxEvent(ctx context.Context, event *domain.OutboxEvent, tx *sql.Tx) error {
+	r.created = append(r.created, *event)
+	return nil
+}
+
+func (r *fakeOutboxRepository) ClaimPendingOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxEvent, error) {
+	r.lease = lease
+	return r.pending[:min(limit, len(r.pending))], nil
+}
+
+func (r *fakeOutboxRepository) MarkOutboxEventPublished(ctx context.Context, id int64) error {
+	r.published = append(r.published, id)
+	return nil
+}
+
+func (r *fakeOutboxRepository) MarkOutboxEventFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	r.failed[id] = nextAttemptAt
+	return nil
+}
+
+func outboxEvent(id int64, attempts int) domain.OutboxEvent {
+	return domain.OutboxEvent{
+		ID:       id,
+		Message:  domain.Message{ID: strconv.FormatInt(id, 10), Topic: "order-service.order.paid.v1", Payload: []byte(`{}`)},
+		Attempts: attempts,
+	}
+}
+
+func TestPublishPendingEvents(t *testing.T) {
+	tests := []struct {
+		name          string
+		pending       []domain.OutboxEvent
+		batchSize     int
+		brokerDown    bool
+		wantPublished []int64
+		wantDelays    map[int64]time.Duration
+	}{
+		{
+			name:          "publishes oldest first",
+			pending:       []domain.OutboxEvent{outboxEvent(1, 0), outboxEvent(2, 0)},
+			batchSize:     100,
+			wantPublished: []int64{1, 2},
+		},
+		{
+			name:          "claims at most the batch size",
+			pending:       []domain.OutboxEvent{outboxEvent(1, 0), outboxEvent(2, 0)},
+			batchSize:     1,
+			wantPublished: []int64{1},
+		},
+		{
+			name:       "broker down backs off per attempt",
+			pending:    []domain.OutboxEvent{outboxEvent(1, 0), outboxEvent(2, 3), outboxEvent(3, 20)},
+			batchSize:  100,
+			brokerDown: true,
+			wantDelays: map[int64]time.Duration{1: time.Second, 2: 8 * time.Second, 3: maxRelayBackoff},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeOutboxRepository{pending: tt.pending, failed: map[int64]time.Time{}}
+			broker := memory.NewBroker()
+			if tt.brokerDown {
+				broker.Close()
+			}
+			cfgStore := config.NewStore(&config.Config{Event: config.EventConfig{RelayBatchSize: tt.batchSize}})
+
+			start := time.Now()
+			NewEventRelayUsecase(repo, broker, cfgStore).PublishPendingEvents(context.Background())
+
+			if want := time.Duration(tt.batchSize)*relayPublishTimeout + claimLeaseMargin; repo.lease != want {
+				t.Errorf("lease = %v, want %v for a batch of %d", repo.lease, want, tt.batchSize)
+			}
+			if len(repo.published) != len(tt.wantPublished) {
+				t.Fatalf("published = %v, want %v", repo.published, tt.wantPublished)
+			}
+			for i, id := range tt.wantPublished {
+				if repo.published[i] != id {
+					t.Errorf("published = %v, want %v", repo.published, tt.wantPublished)
+				}
+			}
+			if got := len(broker.Messages()); got != len(tt.wantPublished) {
+				t.Errorf("broker has %d messages, want %d", got, len(tt.wantPublished))
+			}
+
+			if len(repo.failed) != len(tt.wantDelays) {
+				t.Fatalf("failed = %v, want %d events", repo.failed, len(tt.wantDelays))
+			}
+			for id, delay := range tt.wantDelays {
+				got := repo.failed[id].Sub(start)
+				if got < delay || got > delay+time.Second {
+					t.Errorf("event %d retried after %v, want %v", id, got, delay)
+				}
+			}
+		})
+	}
+}
+
+func TestEmitEvent(t *testing.T) {
+	order := domain.Order{
+		ID:             1,
+		ProductID:      11,
+		Quantity:       2,
+		UserID:         7,
+		ShopID:         3,
+		Status:         domain.OrderStatusWaitingPayment,
+		Notes:          "leave at the door",
+		DeliveryMethod: domain.DeliveryMethodStandard,
+		ShippingAddress: &domain.ShippingAddress{
+			RecipientName: "Budi Santoso", Phone: "+6281234567890", AddressLine1: "Jl. Sudirman 1", City: "Jakarta", PostalCode: "10210", Country: "ID",
+		},
+		Pricing: domain.OrderPricing{UnitPrice: 50000, Subtotal: 100000, ShippingFee: 10000, Total: 110000},
+	}
+
+	tests := []struct {
+		name      string
+		publisher string
+		eventType domain.OrderEventType
+		wantTopic string
+	}{
+		{name: "created", publisher: "nats", eventType: domain.OrderEventCreated, wantTopic: "order-service.order.created.v1"},
+		{name: "paid", publisher: "file", eventType: domain.OrderEventPaid, wantTopic: "order-service.order.paid.v1"},
+		{name: "webhooks only", publisher: "none", eventType: domain.OrderEventCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, webhooks, outbox := newTestOrderUsecase(&fakeOrderRepository{orders: map[int64]domain.Order{1: order}})
+			u.cfg = config.NewStore(&config.Config{Event: config.EventConfig{Publisher: tt.publisher, TopicPrefix: "order-service"}})
+
+			if err := u.emitEvent(context.Background(), nil, tt.eventType, order.ID); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(webhooks.events) != 1 {
+				t.Fatalf("webhook events = %+v, want one", webhooks.events)
+			}
+			event := webhooks.events[0]
+			if event.Type != tt.eventType || event.Version != domain.OrderEventVersion || event.Data != domain.NewOrderEventData(order) {
+				t.Errorf("event = %+v, want %s of order %d", event, tt.eventType, order.ID)
+			}
+
+			if tt.wantTopic == "" {
+				if len(outbox.created) != 0 {
+					t.Errorf("outbox rows = %+v, want none", outbox.created)
+				}
+				return
+			}
+			if len(outbox.created) != 1 {
+				t.Fatalf("outbox rows = %+v, want one", outbox.created)
+			}
+			msg := outbox.created[0].Message
+			if msg.ID != event.ID || msg.Topic != tt.wantTopic || msg.Key != "1" {
+				t.Errorf("outbox message = %+v, want %s keyed by the order ID", msg, tt.wantTopic)
+			}
+			for _, private := range []string{"shipping_address", order.ShippingAddress.Phone, order.ShippingAddress.RecipientName, order.Notes} {
+				if strings.Contains(string(msg.Payload), private) {
+					t.Errorf("payload %s contains %q", msg.Payload, private)
+				}
+			}
+		})
+	}
+}
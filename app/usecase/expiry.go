@@ -0,0 +1,112 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"time"
+)
+
+// ExtendOrderExpiry gives users paying by bank transfer more time. High
+// demand products are refused, their stock should not stay held for long.
+func (u *orderUsecase) ExtendOrderExpiry(ctx context.Context, userID, id int64, req domain.OrderExtendRequest) (domain.Order, error) {
+	maxSeconds := u.cfg.Get().OrderMaxExtensionSeconds
+	if maxSeconds == 0 {
+		return domain.Order{}, fmt.Errorf("%w: payment window extensions are disabled", domain.ErrConflict)
+	}
+	if req.ExtendSeconds > maxSeconds {
+		return domain.Order{}, fmt.Errorf("%w: extend_seconds must be at most %d", domain.ErrBadRequest, maxSeconds)
+	}
+	extension := req.ExtendSeconds
+	if extension == 0 {
+		extension = maxSeconds
+	}
+
+	err := u.orderRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		order, err := u.orderRepository.GetOrderByIDForUpdate(ctx, id, tx)
+		if err != nil {
+			return err
+		}
+
+		if order.UserID != userID {
+			slog.ErrorContext(ctx, "[orderUsecase] ExtendOrderExpiry", "user ID mismatch", "unauthorized access")
+			return domain.ErrUnauthorized
+		}
+		if err := checkExpectedVersion(ctx, order); err != nil {
+			return err
+		}
+		if order.Status != domain.OrderStatusWaitingPayment {
+			slog.ErrorContext(ctx, "[orderUsecase] ExtendOrderExpiry", "order is not waiting for payment", string(order.Status))
+			return fmt.Errorf("%w: order is %s", domain.ErrConflict, order.Status)
+		}
+		if time.Now().After(order.ExpiredAt) {
+			slog.ErrorContext(ctx, "[orderUsecase] ExtendOrderExpiry", "order expired", order.ExpiredAt)
+			return fmt.Errorf("%w: payment window has ended", domain.ErrConflict)
+		}
+
+		product, err := u.productRepository.GetProductByID(ctx, order.ProductID)
+		if err != nil {
+			slog.ErrorContext(ctx, "[orderUsecase] ExtendOrderExpiry", "failed to get product", err)
+			return err
+		}
+		if err := checkExtendable(ctx, order, product); err != nil {
+			return err
+		}
+
+		expiredAt := order.ExpiredAt.Add(time.Duration(extension) * time.Second)
+		if err := u.orderRepository.ExtendExpiry(ctx, order.ID, order.Version, expiredAt, tx); err != nil {
+			return err
+		}
+		if err := u.recordExpiryExtension(ctx, tx, order, userID, expiredAt); err != nil {
+			return err
+		}
+
+		actor := domain.Actor{Type: domain.ActorTypeUser, ID: userID}
+		if err := u.recordAudit(ctx, tx, actor, domain.AuditActionExpiryExtend, order.ID, &order); err != nil {
+			return err
+		}
+
+		adjustReq := domain.ReservedStockAdjustRequest{Quantity: order.Quantity, ExpiredAt: &expiredAt}
+		if err := u.stockRepository.AdjustReservedStock(ctx, order.ID, adjustReq); err != nil {
+			slog.ErrorContext(ctx, "[orderUsecase] ExtendOrderExpiry", "failed to extend reserved stock", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] ExtendOrderExpiry", "transaction", err)
+		return domain.Order{}, err
+	}
+
+	slog.InfoContext(ctx, "[orderUsecase] success ExtendOrderExpiry", "order_id", id, "extend_seconds", extension)
+	return u.orderRepository.GetOrderByID(ctx, id)
+}
+
+// checkExtendable refuses a second payment window extension of an order, and
+// any extension of a high demand product. Restarting the window on an edit
+// counts as the extension.
+func checkExtendable(ctx context.Context, order domain.Order, product domain.Product) error {
+	if order.ExpiryExtendedAt != nil {
+		slog.WarnContext(ctx, "[orderUsecase] checkExtendable", "already extended", order.ExpiryExtendedAt)
+		return domain.ErrExpiryExtended
+	}
+	if product.HighDemand {
+		slog.WarnContext(ctx, "[orderUsecase] checkExtendable", "high demand product", product.ID)
+		return domain.ErrHighDemandProduct
+	}
+	return nil
+}
+
+func (u *orderUsecase) recordExpiryExtension(ctx context.Context, tx *sql.Tx, order domain.Order, userID int64, expiredAt time.Time) error {
+	history := domain.OrderHistory{
+		OrderID:    order.ID,
+		FromStatus: order.Status,
+		ToStatus:   order.Status,
+		ActorType:  domain.ActorTypeUser,
+		ActorID:    userID,
+		Note:       "payment window extended to " + expiredAt.UTC().Format(time.RFC3339),
+	}
+	return u.orderRepository.CreateOrderHistory(ctx, &history, tx)
+}
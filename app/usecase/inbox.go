@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"order-service/app/domain"
+)
+
+type inboxUsecase struct {
+	inboxRepository domain.InboxRepository
+}
+
+func NewInboxUsecase(inboxRepository domain.InboxRepository) domain.InboxUsecase {
+	return &inboxUsecase{
+		inboxRepository: inboxRepository,
+	}
+}
+
+func (u *inboxUsecase) DeadLetter(ctx context.Context, delivery domain.Delivery, cause error) error {
+	deadLetter := domain.DeadLetter{
+		MessageID: delivery.ID,
+		Topic:     delivery.Topic,
+		Payload:   delivery.Payload,
+		Attempts:  delivery.Attempt,
+		Error:     cause.Error(),
+	}
+	if err := u.inboxRepository.CreateDeadLetter(ctx, &deadLetter); err != nil {
+		slog.ErrorContext(ctx, "[inboxUsecase] DeadLetter", "failed to create dead letter", err)
+		return err
+	}
+
+	slog.WarnContext(ctx, "[inboxUsecase] message dead-lettered", "message_id", delivery.ID, "topic", delivery.Topic, "attempts", delivery.Attempt, "error", cause)
+	return nil
+}
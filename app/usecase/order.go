@@ -3,43 +3,99 @@ package usecase
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log/slog"
 	"order-service/app/domain"
 	"order-service/config"
+	"order-service/pkg/ctxutil"
 	"time"
 )
 
 type orderUsecase struct {
-	orderRepository domain.OrderRepository
-	stockRepository domain.StockRepository
-	cfg             *config.Config
+	orderRepository       domain.OrderRepository
+	stockRepository       domain.StockRepository
+	auditRepository       domain.AuditRepository
+	webhookRepository     domain.WebhookRepository
+	outboxRepository      domain.OutboxRepository
+	inboxRepository       domain.InboxRepository
+	latePaymentRepository domain.LatePaymentRepository
+	refundRepository      domain.RefundRepository
+	productRepository     domain.ProductRepository
+	couponRepository      domain.CouponRepository
+	taxCalculator         domain.TaxCalculator
+	cfg                   *config.Store
 }
 
-func NewOrderUsecase(orderRepository domain.OrderRepository, stockRepository domain.StockRepository, cfg *config.Config) domain.OrderUsecase {
+func NewOrderUsecase(orderRepository domain.OrderRepository, stockRepository domain.StockRepository, auditRepository domain.AuditRepository,
+	webhookRepository domain.WebhookRepository, outboxRepository domain.OutboxRepository,
+	inboxRepository domain.InboxRepository, latePaymentRepository domain.LatePaymentRepository,
+	refundRepository domain.RefundRepository, productRepository domain.ProductRepository, couponRepository domain.CouponRepository,
+	taxCalculator domain.TaxCalculator, cfg *config.Store) domain.OrderUsecase {
 	return &orderUsecase{
-		orderRepository: orderRepository,
-		stockRepository: stockRepository,
-		cfg:             cfg,
+		orderRepository:       orderRepository,
+		stockRepository:       stockRepository,
+		auditRepository:       auditRepository,
+		webhookRepository:     webhookRepository,
+		outboxRepository:      outboxRepository,
+		inboxRepository:       inboxRepository,
+		latePaymentRepository: latePaymentRepository,
+		refundRepository:      refundRepository,
+		productRepository:     productRepository,
+		couponRepository:      couponRepository,
+		taxCalculator:         taxCalculator,
+		cfg:                   cfg,
+	}
+}
+
+// newOrder builds the unpriced order of a create request.
+func (u *orderUsecase) newOrder(userID int64, req domain.OrderCreateRequest) domain.Order {
+	req.OrderShipping.Normalize()
+	return domain.Order{
+		ShopID:          req.ShopID,
+		ProductID:       req.ProductID,
+		Quantity:        req.Quantity,
+		UserID:          userID,
+		Status:          domain.OrderStatusWaitingPayment,
+		Notes:           req.Notes,
+		ExpiredAt:       time.Now().Add(time.Second * time.Duration(u.cfg.Get().OrderExpiredDurationSeconds)),
+		DeliveryMethod:  req.DeliveryMethod,
+		ShippingAddress: req.ShippingAddress,
 	}
 }
 
 func (u *orderUsecase) CreateOrder(ctx context.Context, userID int64, req domain.OrderCreateRequest) (domain.Order, error) {
-	order := domain.Order{
-		ProductID: req.ProductID,
-		Quantity:  req.Quantity,
-		UserID:    userID,
-		Status:    domain.OrderStatusWaitingPayment,
-		ExpiredAt: time.Now().Add(time.Second * time.Duration(u.cfg.OrderExpiredDurationSeconds)),
+	order := u.newOrder(userID, req)
+
+	product, err := u.priceOrder(ctx, &order)
+	if err != nil {
+		return domain.Order{}, err
+	}
+	if req.QuoteToken != "" {
+		if err := u.holdQuotedPrices(ctx, &order, req); err != nil {
+			return domain.Order{}, err
+		}
 	}
 
-	err := u.orderRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+	err = u.orderRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := u.prepareOrder(ctx, tx, &order, product, req.CouponCode); err != nil {
+			return err
+		}
+
 		err := u.orderRepository.CreateOrder(ctx, &order, tx)
 		if err != nil {
 			slog.ErrorContext(ctx, "[orderUsecase] CreateOrder", "failed to create order", err)
 			return err
 		}
 
+		if redemption := order.Pricing.Coupon; redemption != nil {
+			redemption.OrderID = order.ID
+			if err := u.couponRepository.CreateRedemption(ctx, redemption, tx); err != nil {
+				return err
+			}
+		}
+
 		reservedStockReq := domain.ReservedStockCreateRequest{
+			ShopID:    order.ShopID,
 			ProductID: order.ProductID,
 			Quantity:  order.Quantity,
 			OrderID:   order.ID,
@@ -50,7 +106,11 @@ func (u *orderUsecase) CreateOrder(ctx context.Context, userID int64, req domain
 			return err
 		}
 
-		return nil
+		actor := domain.Actor{Type: domain.ActorTypeUser, ID: userID}
+		if err := u.recordAudit(ctx, tx, actor, domain.AuditActionOrderCreate, order.ID, nil); err != nil {
+			return err
+		}
+		return u.emitEvent(ctx, tx, domain.OrderEventCreated, order.ID)
 	})
 	if err != nil {
 		return domain.Order{}, err
@@ -59,6 +119,50 @@ func (u *orderUsecase) CreateOrder(ctx context.Context, userID int64, req domain
 	return order, nil
 }
 
+// prepareOrder runs the checks and the discounts of a priced order that
+// CreateOrder and QuoteOrder share. It writes nothing, the coupon and the
+// user lock are held until tx ends.
+func (u *orderUsecase) prepareOrder(ctx context.Context, tx *sql.Tx, order *domain.Order, product domain.Product, couponCode string) error {
+	if err := u.checkUnpaidLimits(ctx, tx, *order); err != nil {
+		return err
+	}
+	if couponCode != "" {
+		if err := u.applyCoupon(ctx, tx, order, couponCode); err != nil {
+			return err
+		}
+	}
+	return u.applyTax(ctx, order, product.Category)
+}
+
+// checkUnpaidLimits enforces the unpaid order caps of a user. The user lock
+// is held until the transaction ends, so concurrent requests of the same user
+// are checked one after another.
+func (u *orderUsecase) checkUnpaidLimits(ctx context.Context, tx *sql.Tx, order domain.Order) error {
+	limits := u.cfg.Get().OrderLimit
+	if limits.MaxUnpaidOrdersPerUser == 0 && limits.MaxReservedQuantityPerProduct == 0 {
+		return nil
+	}
+
+	if err := u.orderRepository.LockUserOrders(ctx, order.UserID, tx); err != nil {
+		return err
+	}
+
+	stats, err := u.orderRepository.GetUnpaidOrderStats(ctx, order.UserID, order.ProductID, tx)
+	if err != nil {
+		return err
+	}
+
+	if limits.MaxUnpaidOrdersPerUser > 0 && stats.OrderCount >= limits.MaxUnpaidOrdersPerUser {
+		slog.WarnContext(ctx, "[orderUsecase] CreateOrder", "unpaid order limit", stats.OrderCount)
+		return fmt.Errorf("%w: at most %d unpaid orders allowed", domain.ErrUnpaidOrderLimit, limits.MaxUnpaidOrdersPerUser)
+	}
+	if limits.MaxReservedQuantityPerProduct > 0 && stats.ProductQuantity+order.Quantity > limits.MaxReservedQuantityPerProduct {
+		slog.WarnContext(ctx, "[orderUsecase] CreateOrder", "reserved quantity limit", stats.ProductQuantity)
+		return fmt.Errorf("%w: at most %d units of this product can be reserved", domain.ErrReservedQuantityLimit, limits.MaxReservedQuantityPerProduct)
+	}
+	return nil
+}
+
 func (u *orderUsecase) GetListByUserID(ctx context.Context, userID int64) ([]domain.Order, error) {
 	orders, err := u.orderRepository.GetListByUserID(ctx, userID)
 	if err != nil {
@@ -83,30 +187,49 @@ func (u *orderUsecase) GetOrderByID(ctx context.Context, userID, id int64) (doma
 	return order, nil
 }
 
+func (u *orderUsecase) GetListByShopID(ctx context.Context, shopID int64, filter domain.OrderFilter) ([]domain.Order, error) {
+	orders, err := u.orderRepository.GetListByShopID(ctx, shopID, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] GetListByShopID", "failed to get list by shop ID", err)
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (u *orderUsecase) GetShopOrderByID(ctx context.Context, shopID, id int64) (domain.Order, error) {
+	order, err := u.orderRepository.GetOrderByID(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] GetShopOrderByID", "failed to get order by ID", err)
+		return domain.Order{}, err
+	}
+
+	if order.ShopID != shopID {
+		slog.ErrorContext(ctx, "[orderUsecase] GetShopOrderByID", "shop ID mismatch", "forbidden access")
+		return domain.Order{}, domain.ErrForbidden
+	}
+
+	return order, nil
+}
+
 func (u *orderUsecase) UpdateStatusOrder(ctx context.Context, req domain.OrderUpdateStatusRequest) error {
-	var reservedStockReq domain.ReservedStockUpdateRequest
-	if req.Status == string(domain.OrderStatusCancelled) {
-		reservedStockReq.Status = "cancelled"
-	} else if req.Status == string(domain.OrderStatusPaid) {
-		reservedStockReq.Status = "completed"
-	} else {
+	status := domain.OrderStatus(req.Status)
+	if _, _, err := paymentResult(status); err != nil {
 		slog.ErrorContext(ctx, "[orderUsecase] UpdateStatusOrder", "invalid status", req.Status)
-		return domain.ErrBadRequest
+		return err
 	}
 
 	if err := u.orderRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
-		err := u.orderRepository.UpdateStatusOrder(ctx, req.OrderID, req.Status, tx)
+		before, err := u.orderRepository.GetOrderByIDForUpdate(ctx, req.OrderID, tx)
 		if err != nil {
-			slog.ErrorContext(ctx, "[orderUsecase] UpdateStatusOrder", "failed to update order status", err)
+			slog.ErrorContext(ctx, "[orderUsecase] UpdateStatusOrder", "failed to get order", err)
 			return err
 		}
 
-		if err := u.stockRepository.UpdateReservedStockStatus(ctx, req.OrderID, reservedStockReq); err != nil {
-			slog.ErrorContext(ctx, "[orderUsecase] UpdateStatusOrder", "failed to update reserved stock status", err)
+		if err := checkExpectedVersion(ctx, before); err != nil {
 			return err
 		}
 
-		return nil
+		return u.applyPayment(ctx, tx, before, status, domain.AuditActionPaymentCallback, "", "")
 	}); err != nil {
 		slog.ErrorContext(ctx, "[orderUsecase] UpdateStatusOrder", "transaction", err)
 		return err
@@ -125,13 +248,15 @@ func (u *orderUsecase) UpdateExpiredOrders(ctx context.Context) {
 	}
 
 	reservedStockReq := domain.ReservedStockUpdateRequest{
-		Status: "cancelled",
+		Status: domain.ReservedStockStatusCancelled,
 	}
 
 	for _, order := range orders {
 
 		err = u.orderRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
-			err := u.orderRepository.UpdateStatusOrder(ctx, order.ID, string(domain.OrderStatusCancelled), tx)
+			// order was read without a lock, a payment that landed since then
+			// makes this fail with ErrVersionConflict and the order is skipped
+			err := u.orderRepository.UpdateStatusOrder(ctx, order.ID, order.Version, string(domain.OrderStatusCancelled), tx)
 			if err != nil {
 				slog.ErrorContext(ctx, "[orderUsecase] UpdateExpiredOrders", "failed to update order status", err)
 				return err
@@ -140,7 +265,8 @@ func (u *orderUsecase) UpdateExpiredOrders(ctx context.Context) {
 				slog.ErrorContext(ctx, "[orderUsecase] UpdateExpiredOrders", "failed to update reserved stock status", err)
 				return err
 			}
-			return nil
+			return u.recordStatusChange(ctx, tx, order, domain.OrderStatusCancelled, domain.ActorTypeSystem, domain.AuditActionOrderExpire,
+				"payment window expired", domain.OrderEventExpired)
 		})
 		if err != nil {
 			slog.ErrorContext(ctx, "[orderUsecase] UpdateExpiredOrders", "transaction", err)
@@ -148,3 +274,14 @@ func (u *orderUsecase) UpdateExpiredOrders(ctx context.Context) {
 	}
 	slog.InfoContext(ctx, "[orderUsecase] UpdateExpiredOrders", "end", time.Now())
 }
+
+// checkExpectedVersion fails with domain.ErrPreconditionFailed when the
+// request sent If-Match and order has moved past that version.
+func checkExpectedVersion(ctx context.Context, order domain.Order) error {
+	expected, ok := ctxutil.GetExpectedVersion(ctx)
+	if !ok || expected == order.Version {
+		return nil
+	}
+	slog.ErrorContext(ctx, "[orderUsecase] checkExpectedVersion", "version mismatch", fmt.Sprintf("expected %d, current %d", expected, order.Version))
+	return fmt.Errorf("%w: order is at version %d", domain.ErrPreconditionFailed, order.Version)
+}
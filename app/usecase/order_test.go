@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"order-service/app/domain"
+	"order-service/config"
+	"testing"
+	"time"
+)
+
+func (r *fakeOrderRepository) CreateOrder(ctx context.Context, order *domain.Order, tx *sql.Tx) error {
+	order.ID = int64(len(r.orders) + 1)
+	r.orders[order.ID] = *order
+	return nil
+}
+
+type fakeProductRepository struct {
+	domain.ProductRepository
+	product domain.Product
+}
+
+func (r *fakeProductRepository) GetProductByID(ctx context.Context, id int64) (domain.Product, error) {
+	if id != r.product.ID {
+		return domain.Product{}, domain.ErrNotFound
+	}
+	return r.product, nil
+}
+
+type fakeStockRepository struct {
+	domain.StockRepository
+	reserved  []domain.ReservedStockCreateRequest
+	released  int64
+	restocked int64
+	statuses  []string
+}
+
+func (r *fakeStockRepository) CreateReservedStock(ctx context.Context, req domain.ReservedStockCreateRequest) error {
+	r.reserved = append(r.reserved, req)
+	return nil
+}
+
+type fakeAuditRepository struct {
+	domain.AuditRepository
+	logs []domain.AuditLog
+}
+
+func (r *fakeAuditRepository) CreateAuditLog(ctx context.Context, log *domain.AuditLog, tx *sql.Tx) error {
+	r.logs = append(r.logs, *log)
+	return nil
+}
+
+type fakeWebhookRepository struct {
+	domain.WebhookRepository
+	events    []domain.OrderEvent
+	due       []domain.WebhookDelivery
+	lease     time.Duration
+	succeeded []int64
+}
+
+func (r *fakeWebhookRepository) EnqueueDeliveries(ctx context.Context, event domain.OrderEvent, payload []byte, tx *sql.Tx) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+type fakeTaxCalculator struct{}
+
+func (fakeTaxCalculator) Calculate(ctx context.Context, req domain.TaxRequest) ([]domain.TaxLine, error) {
+	return []domain.TaxLine{}, nil
+}
+
+// newTestOrderUsecase wires an orderUsecase to the fakes, with product 1 of
+// shop 3 for sale and events published to the broker.
+func newTestOrderUsecase(orders *fakeOrderRepository) (*orderUsecase, *fakeWebhookRepository, *fakeOutboxRepository) {
+	webhooks := &fakeWebhookRepository{}
+	outbox := &fakeOutboxRepository{}
+	cfgStore := config.NewStore(&config.Config{
+		OrderExpiredDurationSeconds: 300,
+		Pricing:                     config.PricingConfig{ShippingFees: "standard:10000"},
+		Event:                       config.EventConfig{Publisher: "nats", TopicPrefix: "order-service"},
+	})
+	u := &orderUsecase{
+		orderRepository:   orders,
+		stockRepository:   &fakeStockRepository{},
+		auditRepository:   &fakeAuditRepository{},
+		webhookRepository: webhooks,
+		outboxRepository:  outbox,
+		productRepository: &fakeProductRepository{product: domain.Product{ID: 1, ShopID: 3, Price: 50000}},
+		taxCalculator:     fakeTaxCalculator{},
+		cfg:               cfgStore,
+	}
+	return u, webhooks, outbox
+}
+
+func TestCreateOrderEmitsCreatedEvent(t *testing.T) {
+	orders := &fakeOrderRepository{orders: map[int64]domain.Order{}}
+	u, webhooks, outbox := newTestOrderUsecase(orders)
+
+	req := domain.OrderCreateRequest{ProductID: 1, Quantity: 2, OrderShipping: domain.OrderShipping{DeliveryMethod: domain.DeliveryMethodPickup}}
+	order, err := u.CreateOrder(context.Background(), 7, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(webhooks.events) != 1 || webhooks.events[0].Type != domain.OrderEventCreated {
+		t.Fatalf("webhook events = %+v, want one %s", webhooks.events, domain.OrderEventCreated)
+	}
+	if len(outbox.created) != 1 {
+		t.Fatalf("outbox rows = %+v, want one", outbox.created)
+	}
+	msg := outbox.created[0].Message
+	if msg.Topic != "order-service.order.created.v1" || msg.Key != "1" || msg.ID != webhooks.events[0].ID {
+		t.Errorf("outbox message = %+v, want the created event of order %d", msg, order.ID)
+	}
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+)
+
+// paymentResult returns the reserved stock status and the event of a payment
+// result, status is paid or cancelled.
+func paymentResult(status domain.OrderStatus) (string, domain.OrderEventType, error) {
+	switch status {
+	case domain.OrderStatusPaid:
+		return domain.ReservedStockStatusCompleted, domain.OrderEventPaid, nil
+	case domain.OrderStatusCancelled:
+		return domain.ReservedStockStatusCancelled, domain.OrderEventCancelled, nil
+	default:
+		return "", "", fmt.Errorf("%w: unsupported payment status %s", domain.ErrBadRequest, status)
+	}
+}
+
+// applyPayment moves the locked order to the payment result status. A result
+// the order already has is ignored, a paid result for a cancelled order goes
+// through applyLatePayment.
+func (u *orderUsecase) applyPayment(ctx context.Context, tx *sql.Tx, order domain.Order, status domain.OrderStatus,
+	action domain.AuditAction, paymentID, note string) error {
+	stockStatus, eventType, err := paymentResult(status)
+	if err != nil {
+		return err
+	}
+
+	if order.Status == status {
+		slog.InfoContext(ctx, "[orderUsecase] applyPayment", "order already in status", string(status), "order_id", order.ID)
+		return nil
+	}
+	if status == domain.OrderStatusPaid && order.Status == domain.OrderStatusCancelled {
+		return u.applyLatePayment(ctx, tx, order, paymentID, note)
+	}
+	if order.Status != domain.OrderStatusWaitingPayment {
+		slog.ErrorContext(ctx, "[orderUsecase] applyPayment", "invalid transition", fmt.Sprintf("%s -> %s", order.Status, status))
+		return fmt.Errorf("%w: order is %s", domain.ErrConflict, order.Status)
+	}
+
+	if err := u.orderRepository.UpdateStatusOrder(ctx, order.ID, order.Version, string(status), tx); err != nil {
+		return err
+	}
+	reservedStockReq := domain.ReservedStockUpdateRequest{Status: stockStatus}
+	if err := u.stockRepository.UpdateReservedStockStatus(ctx, order.ID, reservedStockReq); err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] applyPayment", "failed to update reserved stock status", err)
+		return err
+	}
+	return u.recordStatusChange(ctx, tx, order, status, domain.ActorTypePayment, action, note, eventType)
+}
+
+// applyLatePayment handles a payment for a cancelled order. An order that
+// expired is paid after all when its coupon can be redeemed and its stock
+// reserved again. An order cancelled on purpose, one whose coupon is used up
+// or one whose stock is gone, becomes refund_required. Either way a late
+// payment record is left for the payment team.
+func (u *orderUsecase) applyLatePayment(ctx context.Context, tx *sql.Tx, order domain.Order, paymentID, note string) error {
+	late := domain.LatePayment{
+		OrderID:   order.ID,
+		PaymentID: paymentID,
+		Outcome:   domain.OrderStatusPaid,
+	}
+	if !order.CancelledByExpiry() {
+		late.Outcome = domain.OrderStatusRefundRequired
+		late.Reason = "order was cancelled before its payment window ended"
+	} else if err := u.redeemCouponAgain(ctx, tx, order); err != nil {
+		if !errors.Is(err, domain.ErrCouponUsageLimit) {
+			return err
+		}
+		late.Outcome = domain.OrderStatusRefundRequired
+		late.Reason = "coupon could not be redeemed again: " + err.Error()
+	} else if err := u.reserveStockAgain(ctx, order); err != nil {
+		late.Outcome = domain.OrderStatusRefundRequired
+		late.Reason = "stock could not be reserved again: " + err.Error()
+		// the order is not paid after all, so its coupon stays released
+		if err := u.couponRepository.ReleaseRedemption(ctx, order.ID, tx); err != nil {
+			return err
+		}
+	}
+
+	if err := u.orderRepository.UpdateStatusOrder(ctx, order.ID, order.Version, string(late.Outcome), tx); err != nil {
+		return err
+	}
+	if err := u.latePaymentRepository.CreateLatePayment(ctx, &late, tx); err != nil {
+		return err
+	}
+
+	eventType := domain.OrderEventPaid
+	if late.Outcome == domain.OrderStatusRefundRequired {
+		eventType = domain.OrderEventRefundRequired
+		note = late.Reason
+	}
+	slog.WarnContext(ctx, "[orderUsecase] applyLatePayment", "order_id", order.ID, "outcome", string(late.Outcome), "reason", late.Reason)
+	return u.recordStatusChange(ctx, tx, order, late.Outcome, domain.ActorTypePayment, domain.AuditActionLatePayment, note, eventType)
+}
+
+// reserveStockAgain creates a new reservation for an expired order and
+// completes it right away, since the order is paid.
+func (u *orderUsecase) reserveStockAgain(ctx context.Context, order domain.Order) error {
+	reservedStockReq := domain.ReservedStockCreateRequest{
+		ShopID:    order.ShopID,
+		ProductID: order.ProductID,
+		Quantity:  order.Quantity,
+		OrderID:   order.ID,
+	}
+	if err := u.stockRepository.CreateReservedStock(ctx, reservedStockReq); err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] reserveStockAgain", "failed to create reserved stock", err)
+		return err
+	}
+
+	completeReq := domain.ReservedStockUpdateRequest{Status: domain.ReservedStockStatusCompleted}
+	if err := u.stockRepository.UpdateReservedStockStatus(ctx, order.ID, completeReq); err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] reserveStockAgain", "failed to complete reserved stock", err)
+		cancelReq := domain.ReservedStockUpdateRequest{Status: domain.ReservedStockStatusCancelled}
+		if err := u.stockRepository.UpdateReservedStockStatus(ctx, order.ID, cancelReq); err != nil {
+			slog.ErrorContext(ctx, "[orderUsecase] reserveStockAgain", "failed to cancel reserved stock", err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (u *orderUsecase) AdminListLatePayments(ctx context.Context, filter domain.LatePaymentFilter) ([]domain.LatePayment, error) {
+	payments, err := u.latePaymentRepository.ListLatePayments(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] AdminListLatePayments", "failed to list late payments", err)
+		return nil, err
+	}
+	return payments, nil
+}
@@ -0,0 +1,70 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+)
+
+// priceOrder fills in the shop and the item and shipping prices of a new
+// order from the product catalog and the configured shipping fees. The
+// product is returned for the later pricing steps.
+func (u *orderUsecase) priceOrder(ctx context.Context, order *domain.Order) (domain.Product, error) {
+	product, err := u.productRepository.GetProductByID(ctx, order.ProductID)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] priceOrder", "failed to get product", err)
+		return domain.Product{}, err
+	}
+	if order.ShopID != 0 && product.ShopID != order.ShopID {
+		slog.ErrorContext(ctx, "[orderUsecase] priceOrder", "shop ID mismatch", fmt.Sprintf("product %d of shop %d", product.ID, product.ShopID))
+		return domain.Product{}, fmt.Errorf("%w: product %d is not sold by shop %d", domain.ErrBadRequest, order.ProductID, order.ShopID)
+	}
+	order.ShopID = product.ShopID
+
+	fees, err := u.cfg.Get().Pricing.ShippingFeeByMethod()
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] priceOrder", "invalid shipping fees", err)
+		return domain.Product{}, err
+	}
+
+	order.Pricing = domain.OrderPricing{
+		UnitPrice:   product.Price,
+		Subtotal:    product.Price * order.Quantity,
+		ShippingFee: fees[string(order.DeliveryMethod)],
+	}
+	order.Pricing.Total = order.Pricing.Subtotal + order.Pricing.ShippingFee
+	return product, nil
+}
+
+// applyTax charges the taxes of the discounted item and shipping amounts,
+// so it runs after the coupon. Exclusive taxes are added to the total.
+func (u *orderUsecase) applyTax(ctx context.Context, order *domain.Order, category string) error {
+	req := domain.TaxRequest{
+		Category:       category,
+		ItemAmount:     order.Pricing.Subtotal,
+		ShippingAmount: order.Pricing.ShippingFee,
+	}
+	if order.ShippingAddress != nil {
+		req.Region = order.ShippingAddress.Country
+	}
+	if coupon := order.Pricing.Coupon; coupon != nil {
+		req.ItemAmount -= coupon.ItemDiscount
+		req.ShippingAmount -= coupon.ShippingDiscount
+	}
+
+	lines, err := u.taxCalculator.Calculate(ctx, req)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] applyTax", "failed to calculate tax", err)
+		return err
+	}
+
+	order.Pricing.TaxLines = lines
+	for _, line := range lines {
+		if !line.Inclusive {
+			order.Pricing.Tax += line.Amount
+		}
+	}
+	order.Pricing.Total += order.Pricing.Tax
+	return nil
+}
@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/pkg"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// QuoteOrder prices a request like CreateOrder does and checks the stock,
+// without creating the order or reserving anything. The prices are only held
+// by a token when quote tokens are enabled.
+func (u *orderUsecase) QuoteOrder(ctx context.Context, userID int64, req domain.OrderCreateRequest) (domain.OrderQuote, error) {
+	order := u.newOrder(userID, req)
+
+	product, err := u.priceOrder(ctx, &order)
+	if err != nil {
+		return domain.OrderQuote{}, err
+	}
+
+	available, err := u.stockRepository.GetAvailableStock(ctx, order.ProductID)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] QuoteOrder", "failed to get available stock", err)
+		return domain.OrderQuote{}, err
+	}
+
+	err = u.orderRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		return u.prepareOrder(ctx, tx, &order, product, req.CouponCode)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] QuoteOrder", "transaction", err)
+		return domain.OrderQuote{}, err
+	}
+
+	cfg := u.cfg.Get()
+	quote := domain.OrderQuote{
+		ShopID:         order.ShopID,
+		ProductID:      order.ProductID,
+		Quantity:       order.Quantity,
+		DeliveryMethod: order.DeliveryMethod,
+		Pricing:        order.Pricing,
+		AvailableStock: available,
+		Available:      available >= order.Quantity,
+		ExpiredAt:      order.ExpiredAt,
+	}
+	if !quote.Available {
+		slog.InfoContext(ctx, "[orderUsecase] QuoteOrder", "stock short", available, "product_id", order.ProductID)
+		return quote, nil
+	}
+	if !cfg.Quote.Enabled {
+		return quote, nil
+	}
+
+	tokenExpiresAt := time.Now().Add(time.Duration(cfg.Quote.TTLSeconds) * time.Second)
+	quote.Token, err = pkg.SignQuoteToken(cfg.Quote.Secret, quoteClaims(order, req.CouponCode), tokenExpiresAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] QuoteOrder", "failed to sign quote", err)
+		return domain.OrderQuote{}, err
+	}
+	quote.TokenExpiresAt = &tokenExpiresAt
+	return quote, nil
+}
+
+func quoteClaims(order domain.Order, couponCode string) pkg.QuoteClaims {
+	claims := pkg.QuoteClaims{
+		UserID:         order.UserID,
+		ShopID:         order.ShopID,
+		ProductID:      order.ProductID,
+		Quantity:       order.Quantity,
+		DeliveryMethod: string(order.DeliveryMethod),
+		CouponCode:     domain.NormalizeCouponCode(couponCode),
+		UnitPrice:      order.Pricing.UnitPrice,
+		ShippingFee:    order.Pricing.ShippingFee,
+	}
+	if order.ShippingAddress != nil {
+		claims.Country = order.ShippingAddress.Country
+	}
+	return claims
+}
+
+// holdQuotedPrices replaces the current product price and shipping fee of a
+// priced order with those of its quote. The coupon and the taxes are applied
+// again, so coupon limits still hold.
+func (u *orderUsecase) holdQuotedPrices(ctx context.Context, order *domain.Order, req domain.OrderCreateRequest) error {
+	cfg := u.cfg.Get().Quote
+	if !cfg.Enabled {
+		slog.WarnContext(ctx, "[orderUsecase] holdQuotedPrices", "quote tokens disabled", order.ProductID)
+		return domain.ErrQuoteInvalid
+	}
+	claims, err := pkg.ParseQuoteToken(cfg.Secret, req.QuoteToken)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		slog.WarnContext(ctx, "[orderUsecase] holdQuotedPrices", "quote expired", err)
+		return domain.ErrQuoteExpired
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "[orderUsecase] holdQuotedPrices", "invalid quote", err)
+		return domain.ErrQuoteInvalid
+	}
+
+	expected := quoteClaims(*order, req.CouponCode)
+	if claims.UserID != expected.UserID || claims.ShopID != expected.ShopID || claims.ProductID != expected.ProductID ||
+		claims.Quantity != expected.Quantity || claims.DeliveryMethod != expected.DeliveryMethod ||
+		claims.Country != expected.Country || claims.CouponCode != expected.CouponCode {
+		slog.WarnContext(ctx, "[orderUsecase] holdQuotedPrices", "quote made for another order", order.ProductID)
+		return domain.ErrQuoteInvalid
+	}
+
+	order.Pricing.UnitPrice = claims.UnitPrice
+	order.Pricing.Subtotal = claims.UnitPrice * order.Quantity
+	order.Pricing.ShippingFee = claims.ShippingFee
+	order.Pricing.Total = order.Pricing.Subtotal + order.Pricing.ShippingFee
+	return nil
+}
@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"order-service/app/domain"
+	"order-service/config"
+	"order-service/pkg"
+	"testing"
+	"time"
+)
+
+func TestCreateOrderWithQuoteToken(t *testing.T) {
+	secret := "quote-secret-quote-secret-quote-secret"
+	claims := pkg.QuoteClaims{UserID: 7, ShopID: 3, ProductID: 1, Quantity: 2, DeliveryMethod: string(domain.DeliveryMethodPickup), UnitPrice: 45000}
+	token, err := pkg.SignQuoteToken(secret, claims, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		quote         config.QuoteConfig
+		wantErr       error
+		wantUnitPrice int64
+	}{
+		{name: "quote tokens enabled", quote: config.QuoteConfig{Enabled: true, Secret: secret, TTLSeconds: 300}, wantUnitPrice: 45000},
+		{name: "quote tokens disabled", quote: config.QuoteConfig{TTLSeconds: 300}, wantErr: domain.ErrQuoteInvalid},
+		{name: "signed with another secret", quote: config.QuoteConfig{Enabled: true, Secret: "another-secret-another-secret-another", TTLSeconds: 300}, wantErr: domain.ErrQuoteInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := &fakeOrderRepository{orders: map[int64]domain.Order{}}
+			u, _, _ := newTestOrderUsecase(orders)
+			cfg := *u.cfg.Get()
+			cfg.Quote = tt.quote
+			u.cfg = config.NewStore(&cfg)
+
+			req := domain.OrderCreateRequest{ProductID: 1, Quantity: 2, OrderShipping: domain.OrderShipping{DeliveryMethod: domain.DeliveryMethodPickup}, QuoteToken: token}
+			order, err := u.CreateOrder(context.Background(), 7, req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			if order.Pricing.UnitPrice != tt.wantUnitPrice {
+				t.Errorf("unit price = %d, want %d", order.Pricing.UnitPrice, tt.wantUnitPrice)
+			}
+		})
+	}
+}
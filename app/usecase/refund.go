@@ -0,0 +1,263 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+)
+
+func (u *orderUsecase) RequestShopRefund(ctx context.Context, shopID, sellerID, id int64, req domain.RefundCreateRequest) (domain.Refund, error) {
+	actor := domain.Actor{Type: domain.ActorTypeSeller, ID: sellerID}
+	return u.requestRefund(ctx, actor, id, req, func(order domain.Order) error {
+		if order.ShopID != shopID {
+			slog.ErrorContext(ctx, "[orderUsecase] RequestShopRefund", "shop ID mismatch", "forbidden access")
+			return domain.ErrForbidden
+		}
+		return nil
+	})
+}
+
+func (u *orderUsecase) RequestAdminRefund(ctx context.Context, adminID, id int64, req domain.RefundCreateRequest) (domain.Refund, error) {
+	actor := domain.Actor{Type: domain.ActorTypeAdmin, ID: adminID}
+	return u.requestRefund(ctx, actor, id, req, nil)
+}
+
+// requestRefund creates a pending refund for units that are neither refunded
+// nor part of another pending refund, and asks the payment provider to pay it
+// out through an order.refund_requested event. check, when set, may refuse
+// the locked order.
+func (u *orderUsecase) requestRefund(ctx context.Context, actor domain.Actor, id int64, req domain.RefundCreateRequest,
+	check func(order domain.Order) error) (domain.Refund, error) {
+	var refund domain.Refund
+	err := u.orderRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		order, err := u.orderRepository.GetOrderByIDForUpdate(ctx, id, tx)
+		if err != nil {
+			return err
+		}
+		if check != nil {
+			if err := check(order); err != nil {
+				return err
+			}
+		}
+		if err := checkExpectedVersion(ctx, order); err != nil {
+			return err
+		}
+
+		if !order.Status.CanBeRefunded() {
+			slog.ErrorContext(ctx, "[orderUsecase] requestRefund", "order cannot be refunded", string(order.Status))
+			return fmt.Errorf("%w: order is %s", domain.ErrConflict, order.Status)
+		}
+
+		summary, err := u.refundRepository.GetRefundSummary(ctx, order.ID, tx)
+		if err != nil {
+			return err
+		}
+		remaining := order.Quantity - summary.Requested
+		quantity := req.Quantity
+		if quantity == 0 {
+			quantity = remaining
+		}
+		if remaining <= 0 || quantity > remaining {
+			slog.ErrorContext(ctx, "[orderUsecase] requestRefund", "refundable quantity", remaining)
+			return fmt.Errorf("%w: %d units can be refunded", domain.ErrConflict, max(remaining, 0))
+		}
+		amount, err := refundAmount(order, summary, quantity, req.Amount)
+		if err != nil {
+			return err
+		}
+
+		refund = domain.Refund{
+			OrderID:         order.ID,
+			Quantity:        quantity,
+			Amount:          amount,
+			Reason:          req.Reason,
+			Status:          domain.RefundStatusPending,
+			RequestedByType: actor.Type,
+			RequestedByID:   actor.ID,
+		}
+		if err := u.refundRepository.CreateRefund(ctx, &refund, tx); err != nil {
+			return err
+		}
+
+		if err := u.recordAudit(ctx, tx, actor, domain.AuditActionRefundRequest, order.ID, &order); err != nil {
+			return err
+		}
+		return u.emitRefundEvent(ctx, tx, domain.OrderEventRefundRequested, order.ID, &refund)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] requestRefund", "transaction", err)
+		return domain.Refund{}, err
+	}
+
+	slog.InfoContext(ctx, "[orderUsecase] success requestRefund", "order_id", id, "refund_id", refund.ID, "quantity", refund.Quantity)
+	return refund, nil
+}
+
+// refundAmount checks the requested amount against what is left of the order
+// total. Without an amount, the units get their share of the total; the last
+// units get the rest, so rounding never leaves money behind.
+func refundAmount(order domain.Order, summary domain.RefundSummary, quantity, requested int64) (int64, error) {
+	remaining := max(order.Pricing.Total-summary.RequestedAmount, 0)
+	if requested > remaining {
+		return 0, fmt.Errorf("%w: at most %d can be refunded", domain.ErrConflict, remaining)
+	}
+	if requested > 0 {
+		return requested, nil
+	}
+	if summary.Requested+quantity >= order.Quantity {
+		return remaining, nil
+	}
+	return min(order.Pricing.Total*quantity/order.Quantity, remaining), nil
+}
+
+func (u *orderUsecase) GetShopOrderRefunds(ctx context.Context, shopID, id int64) ([]domain.Refund, error) {
+	if _, err := u.GetShopOrderByID(ctx, shopID, id); err != nil {
+		return nil, err
+	}
+	return u.listRefunds(ctx, id)
+}
+
+func (u *orderUsecase) AdminGetOrderRefunds(ctx context.Context, id int64) ([]domain.Refund, error) {
+	if _, err := u.AdminGetOrderByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return u.listRefunds(ctx, id)
+}
+
+func (u *orderUsecase) listRefunds(ctx context.Context, orderID int64) ([]domain.Refund, error) {
+	refunds, err := u.refundRepository.ListRefundsByOrderID(ctx, orderID)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] listRefunds", "failed to list refunds", err)
+		return nil, err
+	}
+	return refunds, nil
+}
+
+// ConfirmRefund is idempotent for a repeated outcome. A succeeded refund of
+// an order that has moved on, like one cancelled meanwhile, is added to the
+// refunded totals without changing the status or the stock.
+func (u *orderUsecase) ConfirmRefund(ctx context.Context, req domain.RefundConfirmRequest) (domain.Refund, error) {
+	var refund domain.Refund
+	err := u.orderRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		var err error
+		refund, err = u.refundRepository.GetRefundByIDForUpdate(ctx, req.RefundID, tx)
+		if err != nil {
+			return err
+		}
+
+		status := domain.RefundStatus(req.Status)
+		if refund.Status != domain.RefundStatusPending {
+			if refund.Status == status {
+				slog.InfoContext(ctx, "[orderUsecase] ConfirmRefund", "refund already in status", string(status), "refund_id", refund.ID)
+				return nil
+			}
+			return fmt.Errorf("%w: refund is %s", domain.ErrConflict, refund.Status)
+		}
+
+		order, err := u.orderRepository.GetOrderByIDForUpdate(ctx, refund.OrderID, tx)
+		if err != nil {
+			return err
+		}
+
+		refund.Status = status
+		refund.ProviderReference = req.ProviderReference
+		if status == domain.RefundStatusFailed {
+			refund.FailureReason = req.Reason
+			return u.refundRepository.UpdateRefundStatus(ctx, &refund, tx)
+		}
+		return u.applyRefund(ctx, tx, order, &refund)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] ConfirmRefund", "transaction", err)
+		return domain.Refund{}, err
+	}
+
+	slog.InfoContext(ctx, "[orderUsecase] success ConfirmRefund", "refund_id", refund.ID, "status", refund.Status)
+	return refund, nil
+}
+
+// applyRefund adds a succeeded refund to the locked order, which becomes
+// refunded once every unit is, and returns the refunded units to the
+// warehouse. A partial refund keeps the status, so the rest of the order can
+// still be fulfilled.
+func (u *orderUsecase) applyRefund(ctx context.Context, tx *sql.Tx, order domain.Order, refund *domain.Refund) error {
+	next := order.Status
+	refund.StockAction = domain.RefundStockNone
+	if order.Status.CanBeRefunded() {
+		refund.StockAction = refundStockAction(order)
+		if order.RefundedQuantity+refund.Quantity >= order.Quantity {
+			next = domain.OrderStatusRefunded
+		}
+	} else {
+		slog.WarnContext(ctx, "[orderUsecase] applyRefund", "order not refundable anymore", string(order.Status), "refund_id", refund.ID)
+	}
+
+	if err := u.refundRepository.UpdateRefundStatus(ctx, refund, tx); err != nil {
+		return err
+	}
+	if err := u.orderRepository.ApplyRefund(ctx, order.ID, order.Version, *refund, next, tx); err != nil {
+		return err
+	}
+	if err := u.returnRefundedStock(ctx, order, refund); err != nil {
+		return err
+	}
+
+	actor := domain.Actor{Type: domain.ActorTypePayment}
+	if next != order.Status {
+		history := domain.OrderHistory{
+			OrderID:    order.ID,
+			FromStatus: order.Status,
+			ToStatus:   next,
+			ActorType:  actor.Type,
+			Note:       fmt.Sprintf("refund %d of %d units", refund.ID, refund.Quantity),
+		}
+		if err := u.orderRepository.CreateOrderHistory(ctx, &history, tx); err != nil {
+			return err
+		}
+	}
+	if err := u.recordAudit(ctx, tx, actor, domain.AuditActionRefundConfirm, order.ID, &order); err != nil {
+		return err
+	}
+	return u.emitRefundEvent(ctx, tx, domain.OrderEventRefunded, order.ID, refund)
+}
+
+// refundStockAction decides what happens to the units of a refund from the
+// order before it. Shipped units are restocked, the reservation of a paid
+// order that was not shipped is partly released, and rejected or late paid
+// orders hold no stock.
+func refundStockAction(order domain.Order) domain.RefundStockAction {
+	if order.ShippedAt != nil {
+		return domain.RefundStockRestock
+	}
+	switch order.Status {
+	case domain.OrderStatusPaid, domain.OrderStatusAccepted:
+		return domain.RefundStockRelease
+	default:
+		return domain.RefundStockNone
+	}
+}
+
+func (u *orderUsecase) returnRefundedStock(ctx context.Context, order domain.Order, refund *domain.Refund) error {
+	switch refund.StockAction {
+	case domain.RefundStockRelease:
+		req := domain.ReservedStockReleaseRequest{Quantity: refund.Quantity}
+		if err := u.stockRepository.ReleaseReservedStock(ctx, order.ID, req); err != nil {
+			slog.ErrorContext(ctx, "[orderUsecase] returnRefundedStock", "failed to release reserved stock", err)
+			return err
+		}
+	case domain.RefundStockRestock:
+		req := domain.RestockRequest{
+			ShopID:    order.ShopID,
+			ProductID: order.ProductID,
+			Quantity:  refund.Quantity,
+			OrderID:   order.ID,
+		}
+		if err := u.stockRepository.Restock(ctx, req); err != nil {
+			slog.ErrorContext(ctx, "[orderUsecase] returnRefundedStock", "failed to restock", err)
+			return err
+		}
+	}
+	return nil
+}
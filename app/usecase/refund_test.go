@@ -0,0 +1,130 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"order-service/app/domain"
+	"testing"
+	"time"
+)
+
+func (r *fakeOrderRepository) ApplyRefund(ctx context.Context, id, version int64, refund domain.Refund, status domain.OrderStatus, tx *sql.Tx) error {
+	order := r.orders[id]
+	order.Status = status
+	order.RefundedQuantity += refund.Quantity
+	order.RefundedAmount += refund.Amount
+	r.orders[id] = order
+	return nil
+}
+
+func (r *fakeOrderRepository) CreateOrderHistory(ctx context.Context, history *domain.OrderHistory, tx *sql.Tx) error {
+	return nil
+}
+
+type fakeRefundRepository struct {
+	domain.RefundRepository
+}
+
+func (r *fakeRefundRepository) UpdateRefundStatus(ctx context.Context, refund *domain.Refund, tx *sql.Tx) error {
+	return nil
+}
+
+func (r *fakeStockRepository) ReleaseReservedStock(ctx context.Context, orderID int64, req domain.ReservedStockReleaseRequest) error {
+	r.released += req.Quantity
+	return nil
+}
+
+func (r *fakeStockRepository) Restock(ctx context.Context, req domain.RestockRequest) error {
+	r.restocked += req.Quantity
+	return nil
+}
+
+func TestApplyRefund(t *testing.T) {
+	shippedAt := time.Now()
+
+	tests := []struct {
+		name          string
+		order         domain.Order
+		quantity      int64
+		wantStatus    domain.OrderStatus
+		wantAction    domain.RefundStockAction
+		wantReleased  int64
+		wantRestocked int64
+	}{
+		{
+			name:         "partial refund of a paid order keeps it paid",
+			order:        domain.Order{Status: domain.OrderStatusPaid, Quantity: 3},
+			quantity:     1,
+			wantStatus:   domain.OrderStatusPaid,
+			wantAction:   domain.RefundStockRelease,
+			wantReleased: 1,
+		},
+		{
+			name:          "later refund of the shipped rest is restocked",
+			order:         domain.Order{Status: domain.OrderStatusShipped, Quantity: 3, RefundedQuantity: 1, ShippedAt: &shippedAt},
+			quantity:      2,
+			wantStatus:    domain.OrderStatusRefunded,
+			wantAction:    domain.RefundStockRestock,
+			wantRestocked: 2,
+		},
+		{
+			name:       "refund of a rejected order holds no stock",
+			order:      domain.Order{Status: domain.OrderStatusRefundPending, Quantity: 3},
+			quantity:   3,
+			wantStatus: domain.OrderStatusRefunded,
+			wantAction: domain.RefundStockNone,
+		},
+		{
+			name:       "order that moved on is left as is",
+			order:      domain.Order{Status: domain.OrderStatusCancelled, Quantity: 3},
+			quantity:   3,
+			wantStatus: domain.OrderStatusCancelled,
+			wantAction: domain.RefundStockNone,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.order.ID = 1
+			orders := &fakeOrderRepository{orders: map[int64]domain.Order{1: tt.order}}
+			u, _, _ := newTestOrderUsecase(orders)
+			u.refundRepository = &fakeRefundRepository{}
+			stock := &fakeStockRepository{}
+			u.stockRepository = stock
+
+			refund := domain.Refund{ID: 5, OrderID: 1, Quantity: tt.quantity, Amount: 1000, Status: domain.RefundStatusSucceeded}
+			if err := u.applyRefund(context.Background(), nil, tt.order, &refund); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := orders.orders[1]
+			if got.Status != tt.wantStatus || got.RefundedQuantity != tt.order.RefundedQuantity+tt.quantity || got.RefundedAmount != 1000 {
+				t.Errorf("order = %s with %d units and %d refunded, want %s", got.Status, got.RefundedQuantity, got.RefundedAmount, tt.wantStatus)
+			}
+			if refund.StockAction != tt.wantAction || stock.released != tt.wantReleased || stock.restocked != tt.wantRestocked {
+				t.Errorf("stock action = %s, released %d, restocked %d, want %s, %d and %d",
+					refund.StockAction, stock.released, stock.restocked, tt.wantAction, tt.wantReleased, tt.wantRestocked)
+			}
+		})
+	}
+}
+
+func TestPartiallyRefundedOrderCanBeFulfilled(t *testing.T) {
+	for _, tt := range []struct{ from, to domain.OrderStatus }{
+		{from: domain.OrderStatusPaid, to: domain.OrderStatusAccepted},
+		{from: domain.OrderStatusAccepted, to: domain.OrderStatusShipped},
+		{from: domain.OrderStatusShipped, to: domain.OrderStatusCompleted},
+	} {
+		order := domain.Order{ID: 1, Status: tt.from, Quantity: 2}
+		orders := &fakeOrderRepository{orders: map[int64]domain.Order{1: order}}
+		u, _, _ := newTestOrderUsecase(orders)
+		u.refundRepository = &fakeRefundRepository{}
+
+		refund := domain.Refund{ID: 5, OrderID: 1, Quantity: 1, Status: domain.RefundStatusSucceeded}
+		if err := u.applyRefund(context.Background(), nil, order, &refund); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := orders.orders[1].Status; !got.CanTransitionTo(tt.to) {
+			t.Errorf("partially refunded %s order is %s and cannot move to %s", tt.from, got, tt.to)
+		}
+	}
+}
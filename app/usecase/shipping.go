@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"time"
+)
+
+// UpdateShipping is only allowed while the payment window is open, once an
+// order is paid the shop may already be packing it for the old address. The
+// shipping fee, the coupon discount and the taxes follow the new delivery.
+func (u *orderUsecase) UpdateShipping(ctx context.Context, userID, id int64, req domain.OrderShipping) (domain.Order, error) {
+	req.Normalize()
+	err := u.orderRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		order, err := u.orderRepository.GetOrderByIDForUpdate(ctx, id, tx)
+		if err != nil {
+			return err
+		}
+
+		if order.UserID != userID {
+			slog.ErrorContext(ctx, "[orderUsecase] UpdateShipping", "user ID mismatch", "unauthorized access")
+			return domain.ErrUnauthorized
+		}
+		if err := checkExpectedVersion(ctx, order); err != nil {
+			return err
+		}
+		if order.Status != domain.OrderStatusWaitingPayment {
+			slog.ErrorContext(ctx, "[orderUsecase] UpdateShipping", "order is not waiting for payment", string(order.Status))
+			return fmt.Errorf("%w: order is %s", domain.ErrConflict, order.Status)
+		}
+		if time.Now().After(order.ExpiredAt) {
+			slog.ErrorContext(ctx, "[orderUsecase] UpdateShipping", "order expired", order.ExpiredAt)
+			return fmt.Errorf("%w: payment window has ended", domain.ErrConflict)
+		}
+
+		updated, err := u.priceShipping(ctx, order, req)
+		if err != nil {
+			return err
+		}
+		if err := u.orderRepository.UpdateShipping(ctx, order.ID, order.Version, updated, tx); err != nil {
+			return err
+		}
+		if coupon := updated.Pricing.Coupon; coupon != nil && coupon.Status == domain.CouponRedemptionRedeemed {
+			if err := u.couponRepository.UpdateRedemptionDiscount(ctx, order.ID, coupon.ItemDiscount, coupon.ShippingDiscount, tx); err != nil {
+				return err
+			}
+		}
+
+		actor := domain.Actor{Type: domain.ActorTypeUser, ID: userID}
+		return u.recordAudit(ctx, tx, actor, domain.AuditActionShippingUpdate, order.ID, &order)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] UpdateShipping", "transaction", err)
+		return domain.Order{}, err
+	}
+
+	slog.InfoContext(ctx, "[orderUsecase] success UpdateShipping", "order_id", id, "delivery_method", req.DeliveryMethod)
+	return u.orderRepository.GetOrderByID(ctx, id)
+}
+
+// priceShipping returns order with the new delivery and the pricing that
+// goes with it. The unit price stays the one the order was created with.
+func (u *orderUsecase) priceShipping(ctx context.Context, order domain.Order, shipping domain.OrderShipping) (domain.Order, error) {
+	fees, err := u.cfg.Get().Pricing.ShippingFeeByMethod()
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] priceShipping", "invalid shipping fees", err)
+		return domain.Order{}, err
+	}
+	product, err := u.productRepository.GetProductByID(ctx, order.ProductID)
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] priceShipping", "failed to get product", err)
+		return domain.Order{}, err
+	}
+
+	if order.Pricing.Coupon != nil {
+		coupon := *order.Pricing.Coupon
+		order.Pricing.Coupon = &coupon
+	}
+	order.DeliveryMethod = shipping.DeliveryMethod
+	order.ShippingAddress = shipping.ShippingAddress
+	order.Pricing.ShippingFee = fees[string(shipping.DeliveryMethod)]
+	if err := u.repriceOrder(ctx, &order, product); err != nil {
+		return domain.Order{}, err
+	}
+	return order, nil
+}
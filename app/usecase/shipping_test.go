@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"order-service/app/domain"
+	"testing"
+	"time"
+)
+
+func (r *fakeOrderRepository) UpdateShipping(ctx context.Context, id, version int64, order domain.Order, tx *sql.Tx) error {
+	r.orders[id] = order
+	return nil
+}
+
+func TestUpdateShipping(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     domain.OrderStatus
+		expiredAt  time.Time
+		wantErr    error
+		wantMethod domain.DeliveryMethod
+	}{
+		{name: "open payment window", status: domain.OrderStatusWaitingPayment, expiredAt: time.Now().Add(time.Minute), wantMethod: domain.DeliveryMethodStandard},
+		{name: "expired order", status: domain.OrderStatusWaitingPayment, expiredAt: time.Now().Add(-time.Minute), wantErr: domain.ErrConflict, wantMethod: domain.DeliveryMethodPickup},
+		{name: "paid order", status: domain.OrderStatusPaid, expiredAt: time.Now().Add(time.Minute), wantErr: domain.ErrConflict, wantMethod: domain.DeliveryMethodPickup},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := &fakeOrderRepository{orders: map[int64]domain.Order{1: {
+				ID: 1, UserID: 7, ProductID: 1, Quantity: 2, Status: tt.status, ExpiredAt: tt.expiredAt,
+				DeliveryMethod: domain.DeliveryMethodPickup,
+				Pricing:        domain.OrderPricing{UnitPrice: 50000, Subtotal: 100000, Total: 100000},
+			}}}
+			u, _, _ := newTestOrderUsecase(orders)
+
+			_, err := u.UpdateShipping(context.Background(), 7, 1, domain.OrderShipping{DeliveryMethod: domain.DeliveryMethodStandard})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := orders.orders[1].DeliveryMethod; got != tt.wantMethod {
+				t.Errorf("delivery method = %s, want %s", got, tt.wantMethod)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+)
+
+func (u *orderUsecase) AcceptOrder(ctx context.Context, shopID, sellerID, id int64) (domain.Order, error) {
+	return u.transitionShopOrder(ctx, shopID, sellerID, id, domain.OrderStatusAccepted, domain.AuditActionOrderAccept, "",
+		func(ctx context.Context, tx *sql.Tx, order domain.Order) error {
+			return u.orderRepository.UpdateStatusOrder(ctx, order.ID, order.Version, string(domain.OrderStatusAccepted), tx)
+		})
+}
+
+// RejectOrder moves a paid order to refund_pending and gives the reserved
+// stock back to the warehouse.
+func (u *orderUsecase) RejectOrder(ctx context.Context, shopID, sellerID, id int64, req domain.OrderRejectRequest) (domain.Order, error) {
+	return u.transitionShopOrder(ctx, shopID, sellerID, id, domain.OrderStatusRefundPending, domain.AuditActionOrderReject, req.Reason,
+		func(ctx context.Context, tx *sql.Tx, order domain.Order) error {
+			if err := u.orderRepository.UpdateStatusOrder(ctx, order.ID, order.Version, string(domain.OrderStatusRefundPending), tx); err != nil {
+				return err
+			}
+
+			reservedStockReq := domain.ReservedStockUpdateRequest{Status: domain.ReservedStockStatusReleased}
+			if err := u.stockRepository.UpdateReservedStockStatus(ctx, order.ID, reservedStockReq); err != nil {
+				slog.ErrorContext(ctx, "[orderUsecase] RejectOrder", "failed to release reserved stock", err)
+				return err
+			}
+			return nil
+		})
+}
+
+func (u *orderUsecase) ShipOrder(ctx context.Context, shopID, sellerID, id int64, req domain.OrderShipRequest) (domain.Order, error) {
+	note := req.Courier + " " + req.TrackingNumber
+	return u.transitionShopOrder(ctx, shopID, sellerID, id, domain.OrderStatusShipped, domain.AuditActionOrderShip, note,
+		func(ctx context.Context, tx *sql.Tx, order domain.Order) error {
+			return u.orderRepository.UpdateShipment(ctx, order.ID, order.Version, req, tx)
+		})
+}
+
+// transitionShopOrder locks the order, checks that it belongs to the shop and
+// may move to next, then applies the change and records it in the order
+// history and the audit log within one transaction.
+func (u *orderUsecase) transitionShopOrder(ctx context.Context, shopID, sellerID, id int64, next domain.OrderStatus, action domain.AuditAction, note string,
+	apply func(ctx context.Context, tx *sql.Tx, order domain.Order) error) (domain.Order, error) {
+	err := u.orderRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		order, err := u.orderRepository.GetOrderByIDForUpdate(ctx, id, tx)
+		if err != nil {
+			return err
+		}
+
+		if order.ShopID != shopID {
+			slog.ErrorContext(ctx, "[orderUsecase] transitionShopOrder", "shop ID mismatch", "forbidden access")
+			return domain.ErrForbidden
+		}
+
+		if err := checkExpectedVersion(ctx, order); err != nil {
+			return err
+		}
+
+		if !order.Status.CanTransitionTo(next) {
+			slog.ErrorContext(ctx, "[orderUsecase] transitionShopOrder", "invalid transition", fmt.Sprintf("%s -> %s", order.Status, next))
+			return fmt.Errorf("%w: order is %s", domain.ErrConflict, order.Status)
+		}
+
+		if err := apply(ctx, tx, order); err != nil {
+			return err
+		}
+
+		history := domain.OrderHistory{
+			OrderID:    order.ID,
+			FromStatus: order.Status,
+			ToStatus:   next,
+			ActorType:  domain.ActorTypeSeller,
+			ActorID:    sellerID,
+			Note:       note,
+		}
+		if err := u.orderRepository.CreateOrderHistory(ctx, &history, tx); err != nil {
+			return err
+		}
+
+		actor := domain.Actor{Type: domain.ActorTypeSeller, ID: sellerID}
+		return u.recordAudit(ctx, tx, actor, action, order.ID, &order)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] transitionShopOrder", "transaction", err)
+		return domain.Order{}, err
+	}
+
+	slog.InfoContext(ctx, "[orderUsecase] success transitionShopOrder", "order_id", id, "status", next, "seller_id", sellerID)
+	return u.orderRepository.GetOrderByID(ctx, id)
+}
@@ -0,0 +1,169 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"time"
+)
+
+// UpdateOrder re-prices an unpaid order for its new quantity with the unit
+// price and shipping fee it was created with. The warehouse is asked last,
+// so a failed re-reservation rolls the whole edit back. Removing a line is
+// refused while it is the last one, which for now is always the case.
+func (u *orderUsecase) UpdateOrder(ctx context.Context, userID, id int64, req domain.OrderUpdateRequest) (domain.Order, error) {
+	err := u.orderRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := u.orderRepository.GetOrderByIDForUpdate(ctx, id, tx)
+		if err != nil {
+			return err
+		}
+
+		if before.UserID != userID {
+			slog.ErrorContext(ctx, "[orderUsecase] UpdateOrder", "user ID mismatch", "unauthorized access")
+			return domain.ErrUnauthorized
+		}
+		if err := checkExpectedVersion(ctx, before); err != nil {
+			return err
+		}
+		if before.Status != domain.OrderStatusWaitingPayment {
+			slog.ErrorContext(ctx, "[orderUsecase] UpdateOrder", "order is not waiting for payment", string(before.Status))
+			return fmt.Errorf("%w: order is %s", domain.ErrConflict, before.Status)
+		}
+		if time.Now().After(before.ExpiredAt) {
+			slog.ErrorContext(ctx, "[orderUsecase] UpdateOrder", "order expired", before.ExpiredAt)
+			return fmt.Errorf("%w: payment window has ended", domain.ErrConflict)
+		}
+		quantity := *req.Quantity
+		if quantity == 0 {
+			slog.ErrorContext(ctx, "[orderUsecase] UpdateOrder", "remove line", domain.ErrLastOrderLine)
+			return domain.ErrLastOrderLine
+		}
+		if quantity == before.Quantity && !req.ResetExpiry {
+			return nil
+		}
+
+		order := before
+		if before.Pricing.Coupon != nil {
+			coupon := *before.Pricing.Coupon
+			order.Pricing.Coupon = &coupon
+		}
+		product, err := u.productRepository.GetProductByID(ctx, before.ProductID)
+		if err != nil {
+			slog.ErrorContext(ctx, "[orderUsecase] UpdateOrder", "failed to get product", err)
+			return err
+		}
+
+		order.Quantity = quantity
+		if req.ResetExpiry {
+			if err := checkExtendable(ctx, before, product); err != nil {
+				return err
+			}
+			now := time.Now()
+			order.ExpiredAt = now.Add(time.Second * time.Duration(u.cfg.Get().OrderExpiredDurationSeconds))
+			order.ExpiryExtendedAt = &now
+		}
+		if err := u.checkEditedQuantity(ctx, tx, before, order.Quantity); err != nil {
+			return err
+		}
+		if err := u.repriceOrder(ctx, &order, product); err != nil {
+			return err
+		}
+
+		if err := u.orderRepository.UpdateQuantity(ctx, order.ID, before.Version, order, tx); err != nil {
+			return err
+		}
+		if coupon := order.Pricing.Coupon; coupon != nil && coupon.Status == domain.CouponRedemptionRedeemed {
+			if err := u.couponRepository.UpdateRedemptionDiscount(ctx, order.ID, coupon.ItemDiscount, coupon.ShippingDiscount, tx); err != nil {
+				return err
+			}
+		}
+		if req.ResetExpiry {
+			if err := u.recordExpiryExtension(ctx, tx, order, userID, order.ExpiredAt); err != nil {
+				return err
+			}
+		}
+
+		actor := domain.Actor{Type: domain.ActorTypeUser, ID: userID}
+		if err := u.recordAudit(ctx, tx, actor, domain.AuditActionOrderUpdate, order.ID, &before); err != nil {
+			return err
+		}
+
+		adjustReq := domain.ReservedStockAdjustRequest{Quantity: order.Quantity}
+		if req.ResetExpiry {
+			adjustReq.ExpiredAt = &order.ExpiredAt
+		}
+		if err := u.stockRepository.AdjustReservedStock(ctx, order.ID, adjustReq); err != nil {
+			slog.ErrorContext(ctx, "[orderUsecase] UpdateOrder", "failed to adjust reserved stock", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] UpdateOrder", "transaction", err)
+		return domain.Order{}, err
+	}
+
+	slog.InfoContext(ctx, "[orderUsecase] success UpdateOrder", "order_id", id, "quantity", *req.Quantity)
+	return u.orderRepository.GetOrderByID(ctx, id)
+}
+
+// checkEditedQuantity applies the quantity caps of CreateOrder to the new
+// quantity of an order. The unpaid stats already count the order itself.
+func (u *orderUsecase) checkEditedQuantity(ctx context.Context, tx *sql.Tx, order domain.Order, quantity int64) error {
+	productLimits, err := u.cfg.Get().OrderRequest.ProductMaxQuantities()
+	if err != nil {
+		slog.ErrorContext(ctx, "[orderUsecase] checkEditedQuantity", "invalid product limits", err)
+		return err
+	}
+	if limit, ok := productLimits[order.ProductID]; ok && quantity > limit {
+		return fmt.Errorf("%w: at most %d units of this product per order", domain.ErrBadRequest, limit)
+	}
+
+	limit := u.cfg.Get().OrderLimit.MaxReservedQuantityPerProduct
+	if limit == 0 || quantity <= order.Quantity {
+		return nil
+	}
+	if err := u.orderRepository.LockUserOrders(ctx, order.UserID, tx); err != nil {
+		return err
+	}
+	stats, err := u.orderRepository.GetUnpaidOrderStats(ctx, order.UserID, order.ProductID, tx)
+	if err != nil {
+		return err
+	}
+	if stats.ProductQuantity-order.Quantity+quantity > limit {
+		slog.WarnContext(ctx, "[orderUsecase] checkEditedQuantity", "reserved quantity limit", stats.ProductQuantity)
+		return fmt.Errorf("%w: at most %d units of this product can be reserved", domain.ErrReservedQuantityLimit, limit)
+	}
+	return nil
+}
+
+// repriceOrder recomputes the totals of an order after its quantity or its
+// delivery changed. The coupon stays redeemed, only its minimum subtotal and
+// that it still discounts something are checked again.
+func (u *orderUsecase) repriceOrder(ctx context.Context, order *domain.Order, product domain.Product) error {
+	pricing := &order.Pricing
+	pricing.Subtotal = pricing.UnitPrice * order.Quantity
+	pricing.Total = pricing.Subtotal + pricing.ShippingFee
+	pricing.Discount = 0
+	pricing.Tax = 0
+	pricing.TaxLines = nil
+
+	if redemption := pricing.Coupon; redemption != nil && redemption.Status == domain.CouponRedemptionRedeemed {
+		coupon, err := u.couponRepository.GetCouponByID(ctx, redemption.CouponID)
+		if err != nil {
+			return err
+		}
+		if pricing.Subtotal < coupon.MinSubtotal {
+			return fmt.Errorf("%w: subtotal must be at least %d", domain.ErrCouponNotEligible, coupon.MinSubtotal)
+		}
+		redemption.ItemDiscount, redemption.ShippingDiscount = coupon.Discount(pricing.Subtotal, pricing.ShippingFee)
+		if redemption.ItemDiscount+redemption.ShippingDiscount == 0 {
+			return fmt.Errorf("%w: nothing to discount", domain.ErrCouponNotEligible)
+		}
+		pricing.Discount = redemption.ItemDiscount + redemption.ShippingDiscount
+		pricing.Total -= pricing.Discount
+	}
+	return u.applyTax(ctx, order, product.Category)
+}
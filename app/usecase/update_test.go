@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"order-service/app/domain"
+	"testing"
+	"time"
+)
+
+func TestUpdateOrder(t *testing.T) {
+	quantity := func(n int64) *int64 { return &n }
+
+	tests := []struct {
+		name      string
+		userID    int64
+		expiredAt time.Time
+		req       domain.OrderUpdateRequest
+		wantErr   error
+	}{
+		{name: "unchanged quantity", userID: 7, expiredAt: time.Now().Add(time.Minute), req: domain.OrderUpdateRequest{Quantity: quantity(2)}},
+		{name: "remove the last line", userID: 7, expiredAt: time.Now().Add(time.Minute), req: domain.OrderUpdateRequest{Quantity: quantity(0)}, wantErr: domain.ErrLastOrderLine},
+		{name: "expired order", userID: 7, expiredAt: time.Now().Add(-time.Minute), req: domain.OrderUpdateRequest{Quantity: quantity(3)}, wantErr: domain.ErrConflict},
+		{name: "order of another user", userID: 8, expiredAt: time.Now().Add(time.Minute), req: domain.OrderUpdateRequest{Quantity: quantity(3)}, wantErr: domain.ErrUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := &fakeOrderRepository{orders: map[int64]domain.Order{
+				1: {ID: 1, UserID: 7, ProductID: 1, Quantity: 2, Status: domain.OrderStatusWaitingPayment, ExpiredAt: tt.expiredAt},
+			}}
+			u, _, _ := newTestOrderUsecase(orders)
+			stock := &fakeStockRepository{}
+			u.stockRepository = stock
+
+			_, err := u.UpdateOrder(context.Background(), tt.userID, 1, tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := orders.orders[1].Quantity; got != 2 {
+				t.Errorf("quantity = %d, want 2", got)
+			}
+			if stock.released != 0 || len(stock.statuses) != 0 {
+				t.Errorf("reserved stock touched: released %d, statuses %v", stock.released, stock.statuses)
+			}
+		})
+	}
+}
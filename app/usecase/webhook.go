@@ -0,0 +1,253 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"order-service/app/domain"
+	"order-service/config"
+	"order-service/pkg"
+	"strconv"
+	"time"
+)
+
+type webhookUsecase struct {
+	webhookRepository domain.WebhookRepository
+	auditRepository   domain.AuditRepository
+	sender            domain.WebhookSender
+	cfg               *config.Store
+}
+
+func NewWebhookUsecase(webhookRepository domain.WebhookRepository, auditRepository domain.AuditRepository, sender domain.WebhookSender, cfg *config.Store) domain.WebhookUsecase {
+	return &webhookUsecase{
+		webhookRepository: webhookRepository,
+		auditRepository:   auditRepository,
+		sender:            sender,
+		cfg:               cfg,
+	}
+}
+
+func (u *webhookUsecase) CreateSubscription(ctx context.Context, adminID int64, req domain.WebhookSubscriptionRequest) (domain.WebhookSubscription, error) {
+	sub := subscriptionFromRequest(req)
+	if sub.Secret == "" {
+		secret, err := pkg.NewWebhookSecret()
+		if err != nil {
+			slog.ErrorContext(ctx, "[webhookUsecase] CreateSubscription", "failed to generate secret", err)
+			return domain.WebhookSubscription{}, err
+		}
+		sub.Secret = secret
+	}
+
+	err := u.webhookRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		if err := u.webhookRepository.CreateSubscription(ctx, &sub, tx); err != nil {
+			return err
+		}
+		after := sub
+		after.Secret = ""
+		return u.recordAudit(ctx, tx, adminID, domain.AuditActionWebhookCreate, domain.AuditResourceWebhookSubscription, sub.ID, nil, after)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookUsecase] CreateSubscription", "failed to create subscription", err)
+		return domain.WebhookSubscription{}, err
+	}
+
+	slog.InfoContext(ctx, "[webhookUsecase] success CreateSubscription", "subscription_id", sub.ID)
+	return sub, nil
+}
+
+func (u *webhookUsecase) GetSubscriptionByID(ctx context.Context, id int64) (domain.WebhookSubscription, error) {
+	sub, err := u.webhookRepository.GetSubscriptionByID(ctx, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookUsecase] GetSubscriptionByID", "failed to get subscription", err)
+		return domain.WebhookSubscription{}, err
+	}
+	return sub, nil
+}
+
+func (u *webhookUsecase) ListSubscriptions(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	subs, err := u.webhookRepository.ListSubscriptions(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookUsecase] ListSubscriptions", "failed to list subscriptions", err)
+		return nil, err
+	}
+	return subs, nil
+}
+
+// UpdateSubscription replaces the subscription settings. The secret is only
+// rotated when a new one is given.
+func (u *webhookUsecase) UpdateSubscription(ctx context.Context, adminID, id int64, req domain.WebhookSubscriptionRequest) (domain.WebhookSubscription, error) {
+	sub := subscriptionFromRequest(req)
+	sub.ID = id
+	err := u.webhookRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := u.webhookRepository.GetSubscriptionByIDForUpdate(ctx, id, tx)
+		if err != nil {
+			return err
+		}
+		if err := u.webhookRepository.UpdateSubscription(ctx, &sub, tx); err != nil {
+			return err
+		}
+		sub.Secret = ""
+		return u.recordAudit(ctx, tx, adminID, domain.AuditActionWebhookUpdate, domain.AuditResourceWebhookSubscription, id, before, sub)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookUsecase] UpdateSubscription", "failed to update subscription", err)
+		return domain.WebhookSubscription{}, err
+	}
+	return sub, nil
+}
+
+func (u *webhookUsecase) DeleteSubscription(ctx context.Context, adminID, id int64) error {
+	err := u.webhookRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := u.webhookRepository.GetSubscriptionByIDForUpdate(ctx, id, tx)
+		if err != nil {
+			return err
+		}
+		if err := u.webhookRepository.DeleteSubscription(ctx, id, tx); err != nil {
+			return err
+		}
+		return u.recordAudit(ctx, tx, adminID, domain.AuditActionWebhookDelete, domain.AuditResourceWebhookSubscription, id, before, nil)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookUsecase] DeleteSubscription", "failed to delete subscription", err)
+		return err
+	}
+	return nil
+}
+
+func (u *webhookUsecase) ListDeliveries(ctx context.Context, filter domain.WebhookDeliveryFilter) ([]domain.WebhookDelivery, error) {
+	deliveries, err := u.webhookRepository.ListDeliveries(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookUsecase] ListDeliveries", "failed to list deliveries", err)
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (u *webhookUsecase) ReplayDelivery(ctx context.Context, adminID, id int64) error {
+	err := u.webhookRepository.WithTransaction(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		before, err := u.webhookRepository.GetDeliveryByIDForUpdate(ctx, id, tx)
+		if err != nil {
+			return err
+		}
+		if err := u.webhookRepository.ReplayDelivery(ctx, id, tx); err != nil {
+			return err
+		}
+		after, err := u.webhookRepository.GetDeliveryByIDForUpdate(ctx, id, tx)
+		if err != nil {
+			return err
+		}
+		return u.recordAudit(ctx, tx, adminID, domain.AuditActionWebhookReplay, domain.AuditResourceWebhookDelivery, id, before, after)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookUsecase] ReplayDelivery", "failed to replay delivery", err)
+		return err
+	}
+	slog.InfoContext(ctx, "[webhookUsecase] success ReplayDelivery", "delivery_id", id)
+	return nil
+}
+
+// recordAudit writes the audit log of an admin change to a webhook
+// subscription or delivery within tx. Snapshots never carry the secret.
+func (u *webhookUsecase) recordAudit(ctx context.Context, tx *sql.Tx, adminID int64, action domain.AuditAction, resourceType domain.AuditResourceType, resourceID int64, before, after any) error {
+	log := domain.AuditLog{
+		ActorType:    domain.ActorTypeAdmin,
+		ActorID:      adminID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+	}
+	if err := writeAuditLog(ctx, tx, u.auditRepository, log, before, after); err != nil {
+		slog.ErrorContext(ctx, "[webhookUsecase] recordAudit", "failed to create audit log", err)
+		return err
+	}
+	return nil
+}
+
+// claimLeaseMargin is added to the claim lease of a batch for the updates
+// made after each send.
+const claimLeaseMargin = 30 * time.Second
+
+// claimLease returns how long a batch of claimed rows is held when they are
+// sent one after another and each send may take up to timeout.
+func claimLease(batchSize int, timeout time.Duration) time.Duration {
+	return time.Duration(batchSize)*timeout + claimLeaseMargin
+}
+
+// DispatchDueDeliveries sends every due delivery once. Deliveries are only
+// marked succeeded after a 2xx response, so a crash in between leads to a
+// resend once the claim lease runs out; receivers deduplicate by X-Webhook-Id.
+func (u *webhookUsecase) DispatchDueDeliveries(ctx context.Context) {
+	cfg := u.cfg.Get().Webhook
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	// deliveries are sent one after another, the lease covers a batch in which
+	// every send times out, so no other replica claims them meanwhile
+	lease := claimLease(cfg.DispatchBatchSize, timeout)
+	leaseEnd := time.Now().Add(lease)
+
+	deliveries, err := u.webhookRepository.ClaimDueDeliveries(ctx, cfg.DispatchBatchSize, lease)
+	if err != nil {
+		slog.ErrorContext(ctx, "[webhookUsecase] DispatchDueDeliveries", "failed to claim deliveries", err)
+		return
+	}
+
+	for i, delivery := range deliveries {
+		// a reload may have raised the timeout, the rest is claimed again once
+		// the lease runs out rather than sent past it
+		if time.Until(leaseEnd) < time.Duration(u.cfg.Get().Webhook.TimeoutSeconds)*time.Second {
+			slog.WarnContext(ctx, "[webhookUsecase] DispatchDueDeliveries", "claim lease ending, deliveries left", len(deliveries)-i)
+			return
+		}
+		u.dispatch(ctx, cfg, delivery)
+	}
+}
+
+func (u *webhookUsecase) dispatch(ctx context.Context, cfg config.WebhookConfig, delivery domain.WebhookDelivery) {
+	now := time.Now()
+	header := http.Header{}
+	header.Set(pkg.WebhookIDHeader, delivery.EventID)
+	header.Set(pkg.WebhookEventHeader, string(delivery.EventType))
+	header.Set(pkg.WebhookTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	header.Set(pkg.WebhookSignatureHeader, pkg.SignWebhookPayload(delivery.Secret, now, delivery.Payload))
+
+	statusCode, err := u.sender.Send(ctx, delivery.URL, header, delivery.Payload)
+	if err == nil {
+		if err := u.webhookRepository.MarkDeliverySucceeded(ctx, delivery.ID, statusCode); err != nil {
+			slog.ErrorContext(ctx, "[webhookUsecase] dispatch", "failed to mark delivery succeeded", err)
+		}
+		return
+	}
+
+	var nextAttemptAt *time.Time
+	attempts := delivery.Attempts + 1
+	if attempts < cfg.MaxAttempts {
+		next := now.Add(webhookBackoff(cfg, attempts))
+		nextAttemptAt = &next
+	}
+	slog.WarnContext(ctx, "[webhookUsecase] dispatch", "delivery_id", delivery.ID, "attempts", attempts, "status_code", statusCode, "error", err)
+	if err := u.webhookRepository.MarkDeliveryFailed(ctx, delivery.ID, statusCode, err.Error(), nextAttemptAt); err != nil {
+		slog.ErrorContext(ctx, "[webhookUsecase] dispatch", "failed to mark delivery failed", err)
+	}
+}
+
+// webhookBackoff doubles the retry delay with every failed attempt.
+func webhookBackoff(cfg config.WebhookConfig, attempts int) time.Duration {
+	delay := time.Duration(cfg.BackoffBaseSeconds) * time.Second
+	maxDelay := time.Duration(cfg.BackoffMaxSeconds) * time.Second
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+func subscriptionFromRequest(req domain.WebhookSubscriptionRequest) domain.WebhookSubscription {
+	sub := domain.WebhookSubscription{
+		URL:    req.URL,
+		Active: req.Active == nil || *req.Active,
+		Secret: req.Secret,
+	}
+	for _, t := range req.EventTypes {
+		sub.EventTypes = append(sub.EventTypes, domain.OrderEventType(t))
+	}
+	return sub
+}
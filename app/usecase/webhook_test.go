@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"order-service/app/domain"
+	"order-service/config"
+	"testing"
+	"time"
+)
+
+func (r *fakeWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDelivery, error) {
+	r.lease = lease
+	return r.due[:min(limit, len(r.due))], nil
+}
+
+func (r *fakeWebhookRepository) MarkDeliverySucceeded(ctx context.Context, id int64, statusCode int) error {
+	r.succeeded = append(r.succeeded, id)
+	return nil
+}
+
+type fakeWebhookSender struct {
+	sent []string
+}
+
+func (s *fakeWebhookSender) Send(ctx context.Context, url string, header http.Header, body []byte) (int, error) {
+	s.sent = append(s.sent, url)
+	return http.StatusOK, nil
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	cfg := config.WebhookConfig{BackoffBaseSeconds: 30, BackoffMaxSeconds: 600}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 5, want: 8 * time.Minute},
+		{attempts: 6, want: 10 * time.Minute},
+		{attempts: 100, want: 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := webhookBackoff(cfg, tt.attempts); got != tt.want {
+			t.Errorf("webhookBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDispatchDueDeliveriesLease(t *testing.T) {
+	tests := []struct {
+		name      string
+		batchSize int
+		timeout   int64
+		wantLease time.Duration
+	}{
+		{name: "covers a batch of timed out sends", batchSize: 50, timeout: 10, wantLease: 500*time.Second + claimLeaseMargin},
+		{name: "single delivery", batchSize: 1, timeout: 5, wantLease: 5*time.Second + claimLeaseMargin},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeWebhookRepository{due: []domain.WebhookDelivery{{ID: 1, URL: "https://crm.example/hooks"}, {ID: 2, URL: "https://bi.example/hooks"}}}
+			sender := &fakeWebhookSender{}
+			cfgStore := config.NewStore(&config.Config{Webhook: config.WebhookConfig{
+				MaxAttempts: 10, DispatchBatchSize: tt.batchSize, TimeoutSeconds: tt.timeout, BackoffBaseSeconds: 30, BackoffMaxSeconds: 600,
+			}})
+			u := &webhookUsecase{webhookRepository: repo, sender: sender, cfg: cfgStore}
+
+			u.DispatchDueDeliveries(context.Background())
+
+			if repo.lease != tt.wantLease {
+				t.Errorf("lease = %v, want %v", repo.lease, tt.wantLease)
+			}
+			if want := min(tt.batchSize, len(repo.due)); len(sender.sent) != want || len(repo.succeeded) != want {
+				t.Errorf("sent = %v, succeeded = %v, want %d deliveries", sender.sent, repo.succeeded, want)
+			}
+		})
+	}
+}
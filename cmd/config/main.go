@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"order-service/config"
+	"os"
+	"text/tabwriter"
+)
+
+// Prints the effective configuration and the source of every key.
+// Values of keys tagged as secret are masked.
+func main() {
+	// keep loader logs out of the printed table
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	entries, err := config.Describe(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to load config:", err)
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE\tORIGIN")
+	for _, e := range entries {
+		value := ""
+		if e.Value != nil {
+			value = fmt.Sprint(e.Value)
+		}
+		if value != "" && config.IsSecret(e.Key) {
+			value = config.RedactedValue
+		}
+		source := e.Source
+		if source == "" {
+			source = "unset"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Key, value, source, e.Origin)
+	}
+	w.Flush()
+}
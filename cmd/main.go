@@ -2,26 +2,38 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"log/slog"
+	"net"
+	"order-service/app/domain"
+	"order-service/app/grpcserver"
 	"order-service/app/handler"
 	"order-service/app/middleware"
+	"order-service/app/repository/broker"
 	"order-service/app/repository/db"
+	"order-service/app/repository/memory"
+	productrepo "order-service/app/repository/product_repo"
 	stockrepo "order-service/app/repository/stock_repo"
+	taxtable "order-service/app/repository/tax_table"
+	webhookclient "order-service/app/repository/webhook_client"
 	"order-service/app/usecase"
 	"order-service/config"
+	"order-service/pkg"
 	"order-service/pkg/logger"
+	"order-service/pkg/validation"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/go-co-op/gocron"
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/healthcheck"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -35,6 +47,25 @@ func main() {
 		return
 	}
 
+	cfgStore := config.NewStore(cfg)
+	if err := logger.SetLevel(cfg.LogLevel); err != nil {
+		slog.Error("failed to set log level", "error", err)
+		return
+	}
+	cfgStore.Subscribe("logger", func(c *config.Config) any { return c.LogLevel }, func(c *config.Config) {
+		if err := logger.SetLevel(c.LogLevel); err != nil {
+			slog.Error("failed to set log level", "error", err)
+		}
+	})
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	go func() {
+		if err := cfgStore.Watch(watchCtx); err != nil {
+			slog.Error("config watcher stopped", "error", err)
+		}
+	}()
+
 	// init database
 	dbConn, err := db.NewPostgres(cfg.Db)
 	if err != nil {
@@ -42,47 +73,173 @@ func main() {
 	}
 	defer dbConn.Close()
 
-	reqValidator := validator.New()
-	stockRepo := stockrepo.NewStockRepository(cfg.WarehouseService.Host, cfg.InternalAuthHeader)
+	reqValidator, err := validation.New(cfgStore)
+	if err != nil {
+		slog.Error("failed to init request validator", "error", err)
+		return
+	}
+	stockRepo := stockrepo.NewStockRepository(cfgStore)
 	orderRepo := db.NewOrderRepository(dbConn)
+	auditRepo := db.NewAuditRepository(dbConn)
+	webhookRepo := db.NewWebhookRepository(dbConn)
+	webhookSender := webhookclient.NewWebhookSender(cfgStore)
+	outboxRepo := db.NewOutboxRepository(dbConn)
+	inboxRepo := db.NewInboxRepository(dbConn)
+	latePaymentRepo := db.NewLatePaymentRepository(dbConn)
+	refundRepo := db.NewRefundRepository(dbConn)
+	productRepo := productrepo.NewProductRepository(cfgStore)
+	couponRepo := db.NewCouponRepository(dbConn)
+	taxCalculator, err := taxtable.NewTableCalculator(cfg.Pricing.TaxRulesFile)
+	if err != nil {
+		slog.Error("failed to load tax rules", "error", err)
+		return
+	}
 
-	orderUsecase := usecase.NewOrderUsecase(orderRepo, stockRepo, cfg)
+	publisher, err := newEventPublisher(context.Background(), cfg.Event)
+	if err != nil {
+		slog.Error("failed to init event publisher", "error", err)
+		return
+	}
 
-	orderHandler := handler.NewOrderHandler(orderUsecase, reqValidator)
+	orderUsecase := usecase.NewOrderUsecase(orderRepo, stockRepo, auditRepo, webhookRepo, outboxRepo, inboxRepo, latePaymentRepo, refundRepo,
+		productRepo, couponRepo, taxCalculator, cfgStore)
+	auditUsecase := usecase.NewAuditUsecase(auditRepo)
+	webhookUsecase := usecase.NewWebhookUsecase(webhookRepo, auditRepo, webhookSender, cfgStore)
+	couponUsecase := usecase.NewCouponUsecase(couponRepo, auditRepo)
 
-	// Initialize HTTP web framework
-	app := fiber.New()
-	app.Use(healthcheck.New(healthcheck.Config{
-		LivenessProbe: func(c *fiber.Ctx) bool {
-			return true
-		},
-		LivenessEndpoint: "/live",
-		ReadinessProbe: func(c *fiber.Ctx) bool {
-			return true
-		},
-		ReadinessEndpoint: "/ready",
-	}))
-	app.Use(recover.New())
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-	}))
-	app.Use(middleware.RequestIDMiddleware())
-
-	handler.SetupRouter(app, orderHandler, cfg)
+	orderHandler := handler.NewOrderHandler(orderUsecase, reqValidator)
+	auditHandler := handler.NewAuditHandler(auditUsecase, reqValidator)
+	webhookHandler := handler.NewWebhookHandler(webhookUsecase, reqValidator)
+	couponHandler := handler.NewCouponHandler(couponUsecase, reqValidator)
 
-	go func() {
-		if err := app.Listen(":" + cfg.Port); err != nil {
-			slog.Error("Failed to listen", "port", cfg.Port)
-			return
+	var keySet *pkg.JWKS
+	if cfg.Jwt.Mode != "hmac" {
+		keySet = pkg.NewJWKS(cfg.Jwt.JWKSURL, time.Duration(cfg.Jwt.JWKSRefreshSeconds)*time.Second)
+		if err := keySet.Refresh(context.Background()); err != nil {
+			// keys are fetched again on the first request
+			slog.Warn("failed to load JWKS", "error", err)
 		}
-	}()
+	}
 
+	var rateLimitStore domain.RateLimitStore
+	if cfg.RateLimit.Store == "postgres" {
+		rateLimitStore = db.NewRateLimitStore(dbConn)
+	} else {
+		rateLimitStore = memory.NewRateLimitStore()
+	}
+	rateLimiter, err := middleware.NewRateLimiter(rateLimitStore, cfgStore)
+	if err != nil {
+		slog.Error("failed to init rate limiter", "error", err)
+		return
+	}
+
+	runServer := cfg.Mode == "server" || cfg.Mode == "all"
+	runWorker := cfg.Mode == "worker" || cfg.Mode == "all"
+
+	var app *fiber.App
+	var grpcServer *grpc.Server
 	s := gocron.NewScheduler(time.UTC)
 
-	// Schedule a job every minute
-	s.Every(1).Minute().Do(func() {
-		orderUsecase.UpdateExpiredOrders(context.Background())
-	})
+	if runServer {
+		// Initialize HTTP web framework
+		// Validate already parsed the list
+		trustedProxies, _ := cfg.Proxy.TrustedProxyList()
+		app = fiber.New(fiber.Config{
+			JSONDecoder:             pkg.StrictJSONUnmarshal,
+			ProxyHeader:             cfg.Proxy.Header,
+			EnableTrustedProxyCheck: true,
+			TrustedProxies:          trustedProxies,
+			EnableIPValidation:      true,
+		})
+		app.Use(healthcheck.New(healthcheck.Config{
+			LivenessProbe: func(c *fiber.Ctx) bool {
+				return true
+			},
+			LivenessEndpoint: "/live",
+			ReadinessProbe: func(c *fiber.Ctx) bool {
+				return true
+			},
+			ReadinessEndpoint: "/ready",
+		}))
+		app.Use(recover.New())
+		app.Use(cors.New(cors.Config{
+			AllowOrigins:  "*",
+			ExposeHeaders: fiber.HeaderETag,
+		}))
+		app.Use(middleware.RequestIDMiddleware())
+		app.Use(middleware.SourceIPMiddleware())
+		app.Use(middleware.IfMatchMiddleware())
+
+		handler.SetupRouter(app, orderHandler, auditHandler, webhookHandler, couponHandler, cfgStore, keySet, rateLimiter)
+
+		go func() {
+			if err := app.Listen(":" + cfg.Port); err != nil {
+				slog.Error("Failed to listen", "port", cfg.Port)
+				return
+			}
+		}()
+
+		if cfg.Grpc.Enabled {
+			grpcServer, err = grpcserver.NewServer(grpcserver.NewOrderServer(orderUsecase, reqValidator, cfgStore), cfgStore)
+			if err != nil {
+				slog.Error("failed to init grpc server", "error", err)
+				return
+			}
+			lis, err := net.Listen("tcp", ":"+cfg.Grpc.Port)
+			if err != nil {
+				slog.Error("Failed to listen", "port", cfg.Grpc.Port, "error", err)
+				return
+			}
+			go func() {
+				if err := grpcServer.Serve(lis); err != nil {
+					slog.Error("grpc server stopped", "error", err)
+				}
+			}()
+		}
+
+		// Schedule a job every minute
+		s.Every(1).Minute().Do(func() {
+			orderUsecase.UpdateExpiredOrders(context.Background())
+		})
+
+		s.Every(1).Minute().Do(func() {
+			rateLimitStore.Cleanup(context.Background())
+		})
+
+		// deliveries are claimed with SKIP LOCKED, so replicas can dispatch concurrently
+		s.Every(10).Seconds().SingletonMode().Do(func() {
+			webhookUsecase.DispatchDueDeliveries(context.Background())
+		})
+	}
+
+	// the relay also runs in worker mode, consumed messages emit order events too
+	if publisher != nil {
+		defer publisher.Close()
+		eventRelay := usecase.NewEventRelayUsecase(outboxRepo, publisher, cfgStore)
+		s.Every(5).Seconds().SingletonMode().Do(func() {
+			eventRelay.PublishPendingEvents(context.Background())
+		})
+	}
+
+	var consumerWg sync.WaitGroup
+	consumerCtx, stopConsumer := context.WithCancel(context.Background())
+	defer stopConsumer()
+	if runWorker {
+		subscriber, err := newEventSubscriber(cfg.Event)
+		if err != nil {
+			slog.Error("failed to init event subscriber", "error", err)
+			return
+		}
+		defer subscriber.Close()
+
+		inboxUsecase := usecase.NewInboxUsecase(inboxRepo)
+		consumer := handler.NewEventConsumer(orderUsecase, inboxUsecase, subscriber, reqValidator, cfgStore)
+		consumerWg.Add(1)
+		go func() {
+			defer consumerWg.Done()
+			consumer.Run(consumerCtx)
+		}()
+	}
 
 	// Start the scheduler asynchronously
 	s.StartAsync()
@@ -91,8 +248,55 @@ func main() {
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 	slog.Info("Gracefully shutdown")
-	err = app.Shutdown()
-	if err != nil {
-		slog.Warn("Unfortunately the shutdown wasn't smooth", "err", err)
+	s.Stop()
+	stopConsumer()
+	consumerWg.Wait()
+	if app != nil {
+		if err := app.Shutdown(); err != nil {
+			slog.Warn("Unfortunately the shutdown wasn't smooth", "err", err)
+		}
+	}
+	if grpcServer != nil {
+		stopGrpcServer(grpcServer, grpcShutdownTimeout)
+	}
+}
+
+// grpcShutdownTimeout bounds how long open WatchOrder streams delay the shutdown.
+const grpcShutdownTimeout = 10 * time.Second
+
+func stopGrpcServer(server *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		slog.Warn("grpc graceful stop timed out, closing open streams")
+		server.Stop()
+	}
+}
+
+// newEventPublisher returns the broker publisher selected by EVENT_PUBLISHER,
+// or nil when events are not published.
+func newEventPublisher(ctx context.Context, cfg config.EventConfig) (domain.EventPublisher, error) {
+	switch cfg.Publisher {
+	case "file":
+		return broker.NewFilePublisher(cfg.FilePath)
+	case "nats":
+		return broker.NewNATSPublisher(ctx, cfg.NATSURL, cfg.NATSStream, cfg.TopicPrefix)
+	default:
+		return nil, nil
+	}
+}
+
+// newEventSubscriber returns the broker subscriber selected by EVENT_SUBSCRIBER.
+func newEventSubscriber(cfg config.EventConfig) (domain.EventSubscriber, error) {
+	switch cfg.Subscriber {
+	case "nats":
+		return broker.NewNATSSubscriber(cfg.NATSURL, cfg.ConsumerName)
+	default:
+		return nil, fmt.Errorf("unsupported event subscriber %q", cfg.Subscriber)
 	}
 }
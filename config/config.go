@@ -2,134 +2,296 @@ package config
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
-	"os"
+	"net"
+	"strconv"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
-	"github.com/spf13/viper"
 )
 
 type Config struct {
 	Port                        string                 `mapstructure:"PORT" validate:"required"`
-	InternalAuthHeader          string                 `mapstructure:"INTERNAL_AUTH_HEADER" validate:"required"`
-	AuthPaymentHeader           string                 `mapstructure:"AUTH_PAYMENT_HEADER" validate:"required"`
+	Mode                        string                 `mapstructure:"APP_MODE" default:"server" validate:"required,oneof=server worker all"`
+	LogLevel                    string                 `mapstructure:"LOG_LEVEL" default:"info" validate:"required,oneof=debug info warn error"`
+	InternalAuthHeader          string                 `mapstructure:"INTERNAL_AUTH_HEADER" validate:"required" secret:"true"`
+	AuthPaymentHeader           string                 `mapstructure:"AUTH_PAYMENT_HEADER" validate:"required" secret:"true"`
 	OrderExpiredDurationSeconds int64                  `mapstructure:"ORDER_EXPIRED_DURATION_SECONDS" validate:"required"`
+	OrderMaxExtensionSeconds    int64                  `mapstructure:"ORDER_MAX_EXTENSION_SECONDS" default:"86400" validate:"gte=0"`
 	Db                          DbConfig               `mapstructure:",squash"`
 	WarehouseService            WarehouseServiceConfig `mapstructure:",squash"`
+	ProductService              ProductServiceConfig   `mapstructure:",squash"`
+	Pricing                     PricingConfig          `mapstructure:",squash"`
+	Quote                       QuoteConfig            `mapstructure:",squash"`
 	Jwt                         JwtConfig              `mapstructure:",squash"`
+	OrderRequest                OrderRequestConfig     `mapstructure:",squash"`
+	Proxy                       ProxyConfig            `mapstructure:",squash"`
+	RateLimit                   RateLimitConfig        `mapstructure:",squash"`
+	OrderLimit                  OrderLimitConfig       `mapstructure:",squash"`
+	Webhook                     WebhookConfig          `mapstructure:",squash"`
+	Event                       EventConfig            `mapstructure:",squash"`
+	Grpc                        GrpcConfig             `mapstructure:",squash"`
 }
 
 type DbConfig struct {
 	Host     string `mapstructure:"DB_HOST" validate:"required"`
 	Port     string `mapstructure:"DB_PORT" validate:"required"`
 	Username string `mapstructure:"DB_USERNAME" validate:"required"`
-	Password string `mapstructure:"DB_PASSWORD" validate:"required"`
+	Password string `mapstructure:"DB_PASSWORD" validate:"required" secret:"true"`
 	DbName   string `mapstructure:"DB_DBNAME" validate:"required"`
-	SSLMode  string `mapstructure:"DB_SSLMODE"`
+	SSLMode  string `mapstructure:"DB_SSLMODE" default:"disable"`
 }
 
+// ProxyConfig names the load balancers in front of the server. The client IP
+// used by the rate limits and the audit log is read from Header only for
+// requests coming from TrustedProxies, a comma separated list of IPs and
+// CIDRs; other requests use the connection address. Header should be one the
+// proxy overwrites, like X-Real-IP, as clients can prepend addresses to
+// X-Forwarded-For. Applied on restart only.
+type ProxyConfig struct {
+	Header         string `mapstructure:"PROXY_HEADER" validate:"required_with=TrustedProxies"`
+	TrustedProxies string `mapstructure:"TRUSTED_PROXIES"`
+}
+
+// TrustedProxyList parses TrustedProxies.
+func (c ProxyConfig) TrustedProxyList() ([]string, error) {
+	var proxies []string
+	if strings.TrimSpace(c.TrustedProxies) == "" {
+		return proxies, nil
+	}
+
+	for _, entry := range strings.Split(c.TrustedProxies, ",") {
+		entry = strings.TrimSpace(entry)
+		if net.ParseIP(entry) == nil {
+			if _, _, err := net.ParseCIDR(entry); err != nil {
+				return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q", entry)
+			}
+		}
+		proxies = append(proxies, entry)
+	}
+	return proxies, nil
+}
+
+// JwtConfig selects how user tokens are verified. In hmac mode only tokens
+// signed with SecretKey are accepted, in jwks mode only RS256/ES256 tokens
+// signed by a key from the JWKS, and hybrid accepts both during migration.
+// The key set is built on startup, so Mode and the JWKS settings are applied
+// on restart only.
 type JwtConfig struct {
-	SecretKey string `mapstructure:"JWT_SECRETKEY" validate:"required"`
-	Expire    int64  `mapstructure:"JWT_EXPIRE" validate:"required"`
+	Mode               string `mapstructure:"JWT_MODE" default:"hmac" validate:"required,oneof=hmac jwks hybrid"`
+	SecretKey          string `mapstructure:"JWT_SECRETKEY" validate:"required_unless=Mode jwks" secret:"true"`
+	Expire             int64  `mapstructure:"JWT_EXPIRE" validate:"required"`
+	JWKSURL            string `mapstructure:"JWT_JWKS_URL" validate:"required_unless=Mode hmac"`
+	JWKSRefreshSeconds int64  `mapstructure:"JWT_JWKS_REFRESH_SECONDS" default:"300" validate:"gt=0"`
+	Issuer             string `mapstructure:"JWT_ISSUER"`
+	Audience           string `mapstructure:"JWT_AUDIENCE"`
+	LeewaySeconds      int64  `mapstructure:"JWT_LEEWAY_SECONDS" default:"30" validate:"gte=0"`
 }
 
-type WarehouseServiceConfig struct {
-	Host string `mapstructure:"WAREHOUSE_SERVICE_HOST" validate:"required"`
+type OrderRequestConfig struct {
+	MaxQuantity        int64  `mapstructure:"ORDER_MAX_QUANTITY" default:"100" validate:"required,gt=0"`
+	ProductMaxQuantity string `mapstructure:"ORDER_PRODUCT_MAX_QUANTITY"`
+	NotesMaxLength     int    `mapstructure:"ORDER_NOTES_MAX_LENGTH" default:"255" validate:"required,gt=0"`
 }
 
-func InitConfig(ctx context.Context) (*Config, error) {
-	var cfg Config
+// ProductMaxQuantities parses ORDER_PRODUCT_MAX_QUANTITY, a comma separated
+// list of product_id:max_quantity pairs, e.g. "12:5,34:10".
+func (c OrderRequestConfig) ProductMaxQuantities() (map[int64]int64, error) {
+	limits := make(map[int64]int64)
+	if strings.TrimSpace(c.ProductMaxQuantity) == "" {
+		return limits, nil
+	}
+
+	for _, pair := range strings.Split(c.ProductMaxQuantity, ",") {
+		productID, maxQty, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid ORDER_PRODUCT_MAX_QUANTITY entry %q", pair)
+		}
+		id, err := strconv.ParseInt(strings.TrimSpace(productID), 10, 64)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid product ID in ORDER_PRODUCT_MAX_QUANTITY entry %q", pair)
+		}
+		qty, err := strconv.ParseInt(strings.TrimSpace(maxQty), 10, 64)
+		if err != nil || qty <= 0 {
+			return nil, fmt.Errorf("invalid max quantity in ORDER_PRODUCT_MAX_QUANTITY entry %q", pair)
+		}
+		limits[id] = qty
+	}
+	return limits, nil
+}
 
-	// Reset viper to avoid any previous configuration
-	viper.Reset()
+// OrderLimitConfig caps what a single user can hold unpaid. Zero disables a limit.
+type OrderLimitConfig struct {
+	MaxUnpaidOrdersPerUser        int64 `mapstructure:"ORDER_MAX_UNPAID_PER_USER" default:"5" validate:"gte=0"`
+	MaxReservedQuantityPerProduct int64 `mapstructure:"ORDER_MAX_RESERVED_QTY_PER_PRODUCT" default:"50" validate:"gte=0"`
+}
 
-	// Make viper case insensitive for environment variables
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+// WebhookConfig controls outbound webhook delivery. A failed delivery is
+// retried after BackoffBaseSeconds, doubling up to BackoffMaxSeconds, until
+// MaxAttempts is reached.
+type WebhookConfig struct {
+	MaxAttempts        int   `mapstructure:"WEBHOOK_MAX_ATTEMPTS" default:"10" validate:"gt=0"`
+	BackoffBaseSeconds int64 `mapstructure:"WEBHOOK_BACKOFF_BASE_SECONDS" default:"30" validate:"gt=0"`
+	BackoffMaxSeconds  int64 `mapstructure:"WEBHOOK_BACKOFF_MAX_SECONDS" default:"3600" validate:"gtefield=BackoffBaseSeconds"`
+	DispatchBatchSize  int   `mapstructure:"WEBHOOK_DISPATCH_BATCH_SIZE" default:"50" validate:"gt=0"`
+	TimeoutSeconds     int64 `mapstructure:"WEBHOOK_TIMEOUT_SECONDS" default:"10" validate:"gt=0"`
+}
 
-	// Set the configuration type
-	viper.SetConfigType("env")
+// EventConfig selects the message broker order events are published to and
+// consumed from. With publisher "none" no events are written to the outbox.
+// Publisher and subscriber are created on startup, so their settings and the
+// consumed topics are applied on restart only. The topic prefix, relay batch
+// size and consumer attempts are reloadable.
+type EventConfig struct {
+	Publisher      string `mapstructure:"EVENT_PUBLISHER" default:"none" validate:"required,oneof=none file nats"`
+	TopicPrefix    string `mapstructure:"EVENT_TOPIC_PREFIX" default:"order-service" validate:"required"`
+	FilePath       string `mapstructure:"EVENT_FILE_PATH" default:"events.ndjson" validate:"required_if=Publisher file"`
+	NATSURL        string `mapstructure:"EVENT_NATS_URL" validate:"required_if=Publisher nats" secret:"true"`
+	NATSStream     string `mapstructure:"EVENT_NATS_STREAM" default:"ORDER_EVENTS" validate:"required_if=Publisher nats"`
+	RelayBatchSize int    `mapstructure:"EVENT_RELAY_BATCH_SIZE" default:"100" validate:"gt=0"`
 
-	// Try to load from .env file if it exists
-	envFile := os.Getenv("ENV_FILE")
-	if envFile == "" {
-		envFile = ".env"
-	}
+	Subscriber            string `mapstructure:"EVENT_SUBSCRIBER" default:"none" validate:"required,oneof=none nats"`
+	ConsumerName          string `mapstructure:"EVENT_CONSUMER_NAME" default:"order-service" validate:"required"`
+	ConsumerMaxAttempts   int    `mapstructure:"EVENT_CONSUMER_MAX_ATTEMPTS" default:"5" validate:"gt=0"`
+	PaymentSucceededTopic string `mapstructure:"EVENT_PAYMENT_SUCCEEDED_TOPIC" default:"payment-service.payment.succeeded.v1" validate:"required"`
+	PaymentFailedTopic    string `mapstructure:"EVENT_PAYMENT_FAILED_TOPIC" default:"payment-service.payment.failed.v1" validate:"required"`
+	StockReleasedTopic    string `mapstructure:"EVENT_STOCK_RELEASED_TOPIC" default:"warehouse-service.stock.released.v1" validate:"required"`
+}
 
-	_, err := os.Stat(envFile)
-	if !os.IsNotExist(err) {
-		viper.SetConfigFile(envFile)
+// Topic returns the broker topic of a versioned event type, e.g.
+// order-service.order.created.v1.
+func (c EventConfig) Topic(eventType string, version int) string {
+	return fmt.Sprintf("%s.%s.v%d", c.TopicPrefix, eventType, version)
+}
 
-		if err := viper.ReadInConfig(); err != nil {
-			slog.WarnContext(ctx, "[InitConfig] ReadInConfig warning, continuing with env vars only", "error", err)
-			// Continue with just environment variables instead of returning error
-		} else {
-			slog.InfoContext(ctx, "[InitConfig] Successfully loaded config file", "file", envFile)
-		}
-	} else {
-		slog.InfoContext(ctx, "[InitConfig] No config file found, using environment variables")
+// GrpcConfig controls the internal gRPC server. In secret mode callers send
+// INTERNAL_AUTH_HEADER as x-internal-auth metadata, in mtls mode they present
+// a client certificate signed by TLSClientCAFile. The server settings are
+// applied on restart only.
+type GrpcConfig struct {
+	Enabled          bool   `mapstructure:"GRPC_ENABLED" default:"false"`
+	Port             string `mapstructure:"GRPC_PORT" default:"9090" validate:"required_if=Enabled true"`
+	AuthMode         string `mapstructure:"GRPC_AUTH_MODE" default:"secret" validate:"required,oneof=secret mtls"`
+	TLSCertFile      string `mapstructure:"GRPC_TLS_CERT_FILE" validate:"required_if=AuthMode mtls"`
+	TLSKeyFile       string `mapstructure:"GRPC_TLS_KEY_FILE" validate:"required_if=AuthMode mtls"`
+	TLSClientCAFile  string `mapstructure:"GRPC_TLS_CLIENT_CA_FILE" validate:"required_if=AuthMode mtls"`
+	WatchPollSeconds int64  `mapstructure:"GRPC_WATCH_POLL_SECONDS" default:"2" validate:"gt=0"`
+}
+
+type WarehouseServiceConfig struct {
+	Host string `mapstructure:"WAREHOUSE_SERVICE_HOST" validate:"required"`
+}
+
+type ProductServiceConfig struct {
+	Host string `mapstructure:"PRODUCT_SERVICE_HOST" validate:"required"`
+}
+
+// PricingConfig holds the delivery prices. ShippingFees is a comma separated
+// list of delivery_method:fee pairs in the minor currency unit, e.g.
+// "standard:10000,express:25000". Methods that are not listed are free.
+// TaxRulesFile is a JSON tax table read on startup, no tax is charged
+// without one.
+type PricingConfig struct {
+	ShippingFees string `mapstructure:"ORDER_SHIPPING_FEES"`
+	TaxRulesFile string `mapstructure:"TAX_RULES_FILE"`
+}
+
+// ShippingFeeByMethod parses ORDER_SHIPPING_FEES.
+func (c PricingConfig) ShippingFeeByMethod() (map[string]int64, error) {
+	fees := make(map[string]int64)
+	if strings.TrimSpace(c.ShippingFees) == "" {
+		return fees, nil
 	}
 
-	// Load environment variables
-	viper.AutomaticEnv()
-
-	// environment variables we're looking for
-	envVars := []string{
-		"PORT",
-		"INTERNAL_AUTH_HEADER",
-		"AUTH_PAYMENT_HEADER",
-		"WAREHOUSE_SERVICE_HOST",
-		"DB_HOST",
-		"DB_PORT",
-		"DB_USERNAME",
-		"DB_PASSWORD",
-		"DB_DBNAME",
-		"DB_SSLMODE",
-		"JWT_SECRETKEY",
-		"JWT_EXPIRE",
-		"ORDER_EXPIRED_DURATION_SECONDS",
+	for _, pair := range strings.Split(c.ShippingFees, ",") {
+		method, fee, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || strings.TrimSpace(method) == "" {
+			return nil, fmt.Errorf("invalid ORDER_SHIPPING_FEES entry %q", pair)
+		}
+		amount, err := strconv.ParseInt(strings.TrimSpace(fee), 10, 64)
+		if err != nil || amount < 0 {
+			return nil, fmt.Errorf("invalid fee in ORDER_SHIPPING_FEES entry %q", pair)
+		}
+		fees[strings.TrimSpace(method)] = amount
 	}
+	return fees, nil
+}
 
-	slog.InfoContext(ctx, "[InitConfig] Environment variables debug:")
+// QuoteConfig signs quote tokens. A token holds the quoted prices for
+// TTLSeconds. Quotes are still priced while tokens are disabled, they just
+// carry no token, so the secret is only needed once Enabled is set.
+type QuoteConfig struct {
+	Enabled    bool   `mapstructure:"QUOTE_ENABLED" default:"false"`
+	Secret     string `mapstructure:"QUOTE_SECRET" validate:"required_if=Enabled true,omitempty,min=32" secret:"true"`
+	TTLSeconds int64  `mapstructure:"QUOTE_TTL_SECONDS" default:"300" validate:"gt=0"`
+}
 
-	// Bind environment variables explicitly to ensure they're mapped correctly
-	for _, key := range envVars {
-		viper.BindEnv(key)
+// InitConfig loads the configuration from, in increasing precedence:
+// struct tag defaults, CONFIG_FILE (yaml or json), the APP_PROFILE specific
+// config file, ENV_FILE, environment variables and KEY_FILE secret files.
+func InitConfig(ctx context.Context) (*Config, error) {
+	cfg, _, err := load(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Unmarshal configuration
-	if err := viper.Unmarshal(&cfg); err != nil {
-		slog.ErrorContext(ctx, "[InitConfig] Unmarshal", "failed bind config", err)
+	// Log the entire configuration after binding
+	slog.InfoContext(ctx, "[InitConfig] Configuration after binding", "config", cfg)
+
+	if err := Validate(ctx, cfg); err != nil {
 		return nil, err
 	}
 
-	// Log the entire configuration after binding
-	slog.InfoContext(ctx, "[InitConfig] Configuration after binding",
-		"PORT", cfg.Port,
-		"DB_DBNAME", cfg.Db.DbName,
-		"DB_SSLMODE", cfg.Db.SSLMode,
-		"INTERNAL_AUTH_HEADER", cfg.InternalAuthHeader,
-		"AUTH_PAYMENT_HEADER", cfg.AuthPaymentHeader,
-	)
-
-	// Validate configuration
+	slog.InfoContext(ctx, "[InitConfig] Config loaded successfully")
+	return cfg, nil
+}
+
+// Validate checks the configuration rules, used both at startup and before
+// a reloaded configuration is applied.
+func Validate(ctx context.Context, cfg *Config) error {
 	validate := validator.New()
 	if err := validate.Struct(cfg); err != nil {
 		validationErrs, ok := err.(validator.ValidationErrors)
 		if ok {
 			for _, validationErr := range validationErrs {
-				slog.ErrorContext(ctx, "[InitConfig] Validation error",
+				slog.ErrorContext(ctx, "[ValidateConfig] Validation error",
 					"field", validationErr.Field(),
 					"namespace", validationErr.Namespace(),
 					"tag", validationErr.Tag(),
 					"value", validationErr.Value())
 			}
 		} else {
-			slog.ErrorContext(ctx, "[InitConfig] Validation", "error", err)
+			slog.ErrorContext(ctx, "[ValidateConfig] Validation", "error", err)
 		}
-		return nil, err
+		return err
 	}
 
-	slog.InfoContext(ctx, "[InitConfig] Config loaded successfully")
-	return &cfg, nil
+	if _, err := cfg.OrderRequest.ProductMaxQuantities(); err != nil {
+		slog.ErrorContext(ctx, "[ValidateConfig] Validation", "error", err)
+		return err
+	}
+
+	if _, err := cfg.Pricing.ShippingFeeByMethod(); err != nil {
+		slog.ErrorContext(ctx, "[ValidateConfig] Validation", "error", err)
+		return err
+	}
+
+	if _, err := cfg.Proxy.TrustedProxyList(); err != nil {
+		slog.ErrorContext(ctx, "[ValidateConfig] Validation", "error", err)
+		return err
+	}
+
+	if _, err := cfg.RateLimit.ParseRules(); err != nil {
+		slog.ErrorContext(ctx, "[ValidateConfig] Validation", "error", err)
+		return err
+	}
+
+	if cfg.Mode != "server" && cfg.Event.Subscriber == "none" {
+		err := fmt.Errorf("APP_MODE %s needs EVENT_SUBSCRIBER", cfg.Mode)
+		slog.ErrorContext(ctx, "[ValidateConfig] Validation", "error", err)
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrustedProxyList(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxies string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", proxies: " "},
+		{name: "addresses and ranges", proxies: "10.0.0.1, 172.16.0.0/12,::1", want: []string{"10.0.0.1", "172.16.0.0/12", "::1"}},
+		{name: "hostname", proxies: "10.0.0.1,lb.internal", wantErr: true},
+		{name: "invalid range", proxies: "10.0.0.0/33", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProxyConfig{TrustedProxies: tt.proxies}.TrustedProxyList()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TrustedProxyList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
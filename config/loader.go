@@ -0,0 +1,247 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Configuration sources, from lowest to highest precedence.
+const (
+	SourceDefault     = "default"
+	SourceConfigFile  = "config_file"
+	SourceProfileFile = "profile_file"
+	SourceEnvFile     = "env_file"
+	SourceEnv         = "env"
+	SourceSecretFile  = "secret_file"
+)
+
+// Entry is a single resolved configuration key together with the source
+// it was taken from.
+type Entry struct {
+	Key    string
+	Value  any
+	Source string
+	// Origin is the file the value was read from, empty for env vars and defaults.
+	Origin string
+}
+
+type layer struct {
+	source string
+	origin string
+	values map[string]any
+	// origins overrides origin per key, used by layers that read one file per key
+	origins map[string]string
+}
+
+// Keys returns every configuration key declared through the mapstructure
+// tags of Config, in declaration order.
+func Keys() []string {
+	return collectKeys(reflect.TypeOf(Config{}))
+}
+
+func collectKeys(t reflect.Type) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if field.Type.Kind() == reflect.Struct && (opts == "squash" || name == "") {
+			keys = append(keys, collectKeys(field.Type)...)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+		keys = append(keys, name)
+	}
+	return keys
+}
+
+func collectDefaults(t reflect.Type, defaults map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if field.Type.Kind() == reflect.Struct && (opts == "squash" || name == "") {
+			collectDefaults(field.Type, defaults)
+			continue
+		}
+		if def, ok := field.Tag.Lookup("default"); ok && name != "" {
+			defaults[name] = def
+		}
+	}
+}
+
+// configFiles returns the base config file and the profile specific file
+// derived from it, e.g. config.yaml and config.prod.yaml for APP_PROFILE=prod.
+func configFiles() (string, string) {
+	base := os.Getenv("CONFIG_FILE")
+	if base == "" {
+		base = "config.yaml"
+	}
+
+	profile := os.Getenv("APP_PROFILE")
+	if profile == "" {
+		return base, ""
+	}
+
+	ext := filepath.Ext(base)
+	return base, strings.TrimSuffix(base, ext) + "." + profile + ext
+}
+
+func envFile() string {
+	if f := os.Getenv("ENV_FILE"); f != "" {
+		return f
+	}
+	return ".env"
+}
+
+func readFileLayer(ctx context.Context, source, path, configType string, keys []string) (layer, error) {
+	l := layer{source: source, origin: path, values: map[string]any{}}
+	if path == "" {
+		return l, nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		slog.InfoContext(ctx, "[InitConfig] Config file not found, skipping", "source", source, "file", path)
+		return l, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if configType != "" {
+		v.SetConfigType(configType)
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return l, fmt.Errorf("read %s %s: %w", source, path, err)
+	}
+
+	for _, key := range keys {
+		if v.IsSet(key) {
+			l.values[key] = v.Get(key)
+		}
+	}
+	slog.InfoContext(ctx, "[InitConfig] Successfully loaded config file", "source", source, "file", path)
+	return l, nil
+}
+
+func envLayer(keys []string) layer {
+	l := layer{source: SourceEnv, values: map[string]any{}}
+	for _, key := range keys {
+		if val, ok := os.LookupEnv(key); ok {
+			l.values[key] = val
+		}
+	}
+	return l
+}
+
+// secretFileLayer resolves KEY_FILE environment variables, reading the value
+// of KEY from the referenced file.
+func secretFileLayer(ctx context.Context, keys []string) (layer, error) {
+	l := layer{source: SourceSecretFile, values: map[string]any{}, origins: map[string]string{}}
+	for _, key := range keys {
+		path, ok := os.LookupEnv(key + "_FILE")
+		if !ok || path == "" {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return l, fmt.Errorf("read %s_FILE: %w", key, err)
+		}
+		if _, ok := os.LookupEnv(key); ok {
+			slog.WarnContext(ctx, "[InitConfig] Both variable and secret file are set, using secret file", "key", key)
+		}
+		l.values[key] = strings.TrimRight(string(content), "\r\n")
+		l.origins[key] = path
+	}
+	return l, nil
+}
+
+// resolve reads every configuration layer and returns the winning value of
+// each key, with the source it came from.
+func resolve(ctx context.Context) (map[string]Entry, error) {
+	keys := Keys()
+
+	defaults := layer{source: SourceDefault, values: map[string]any{}}
+	collectDefaults(reflect.TypeOf(Config{}), defaults.values)
+
+	baseFile, profileFile := configFiles()
+
+	base, err := readFileLayer(ctx, SourceConfigFile, baseFile, "", keys)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := readFileLayer(ctx, SourceProfileFile, profileFile, "", keys)
+	if err != nil {
+		return nil, err
+	}
+	dotenv, err := readFileLayer(ctx, SourceEnvFile, envFile(), "env", keys)
+	if err != nil {
+		// a broken .env file is not fatal, env vars may still provide everything
+		slog.WarnContext(ctx, "[InitConfig] ReadInConfig warning, continuing without env file", "error", err)
+		dotenv = layer{source: SourceEnvFile, values: map[string]any{}}
+	}
+	secrets, err := secretFileLayer(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := []layer{defaults, base, profile, dotenv, envLayer(keys), secrets}
+
+	resolved := make(map[string]Entry, len(keys))
+	for _, l := range layers {
+		for key, val := range l.values {
+			origin := l.origin
+			if o, ok := l.origins[key]; ok {
+				origin = o
+			}
+			resolved[key] = Entry{Key: key, Value: val, Source: l.source, Origin: origin}
+		}
+	}
+	return resolved, nil
+}
+
+func load(ctx context.Context) (*Config, map[string]Entry, error) {
+	resolved, err := resolve(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "[InitConfig] resolve", "error", err)
+		return nil, nil, err
+	}
+
+	v := viper.New()
+	for key, entry := range resolved {
+		v.Set(key, entry.Value)
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		slog.ErrorContext(ctx, "[InitConfig] Unmarshal", "failed bind config", err)
+		return nil, nil, err
+	}
+	return &cfg, resolved, nil
+}
+
+// Describe returns the effective configuration with the source of every key,
+// sorted by key. Keys that are not set anywhere are reported with an empty source.
+func Describe(ctx context.Context) ([]Entry, error) {
+	_, resolved, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(resolved))
+	for _, key := range Keys() {
+		entry, ok := resolved[key]
+		if !ok {
+			entry = Entry{Key: key}
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
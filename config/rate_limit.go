@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type RateLimitConfig struct {
+	Enabled bool   `mapstructure:"RATE_LIMIT_ENABLED" default:"true"`
+	Store   string `mapstructure:"RATE_LIMIT_STORE" default:"memory" validate:"required,oneof=memory postgres"`
+	Rules   string `mapstructure:"RATE_LIMIT_RULES" default:"POST /order-service/orders user=10/1m ip=30/1m"`
+}
+
+// RateLimit is a number of requests allowed per window.
+type RateLimit struct {
+	Requests int64
+	Window   time.Duration
+}
+
+// RateLimitRule limits one route, per user and per client IP. A zero limit
+// is not enforced.
+type RateLimitRule struct {
+	Method string
+	Path   string
+	User   RateLimit
+	IP     RateLimit
+}
+
+// ParseRules parses RATE_LIMIT_RULES, rules separated by ";" in the form
+// "METHOD /path/:param user=N/window ip=N/window", e.g.
+// "POST /order-service/orders user=10/1m ip=30/1m".
+func (c RateLimitConfig) ParseRules() ([]RateLimitRule, error) {
+	var rules []RateLimitRule
+	for _, raw := range strings.Split(c.Rules, ";") {
+		fields := strings.Fields(raw)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("invalid RATE_LIMIT_RULES entry %q", raw)
+		}
+
+		rule := RateLimitRule{Method: strings.ToUpper(fields[0]), Path: fields[1]}
+		for _, f := range fields[2:] {
+			scope, spec, ok := strings.Cut(f, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid limit %q in RATE_LIMIT_RULES entry %q", f, raw)
+			}
+			limit, err := parseRateLimit(spec)
+			if err != nil {
+				return nil, fmt.Errorf("invalid limit %q in RATE_LIMIT_RULES entry %q: %w", f, raw, err)
+			}
+			switch scope {
+			case "user":
+				rule.User = limit
+			case "ip":
+				rule.IP = limit
+			default:
+				return nil, fmt.Errorf("unknown scope %q in RATE_LIMIT_RULES entry %q", scope, raw)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func parseRateLimit(spec string) (RateLimit, error) {
+	n, window, ok := strings.Cut(spec, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("expected N/window")
+	}
+	requests, err := strconv.ParseInt(n, 10, 64)
+	if err != nil || requests <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid request count %q", n)
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d < time.Second {
+		return RateLimit{}, fmt.Errorf("invalid window %q", window)
+	}
+	return RateLimit{Requests: requests, Window: d}, nil
+}
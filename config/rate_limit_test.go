@@ -0,0 +1,55 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		want    []RateLimitRule
+		wantErr bool
+	}{
+		{
+			name:  "default",
+			rules: "POST /order-service/orders user=10/1m ip=30/1m",
+			want: []RateLimitRule{
+				{Method: "POST", Path: "/order-service/orders", User: RateLimit{Requests: 10, Window: time.Minute}, IP: RateLimit{Requests: 30, Window: time.Minute}},
+			},
+		},
+		{
+			name:  "several rules with one scope each",
+			rules: "post /order-service/orders/:id/cancel user=3/30s; GET /order-service/quotes ip=100/1h;",
+			want: []RateLimitRule{
+				{Method: "POST", Path: "/order-service/orders/:id/cancel", User: RateLimit{Requests: 3, Window: 30 * time.Second}},
+				{Method: "GET", Path: "/order-service/quotes", IP: RateLimit{Requests: 100, Window: time.Hour}},
+			},
+		},
+		{name: "empty", rules: ""},
+		{name: "missing limit", rules: "POST /order-service/orders", wantErr: true},
+		{name: "missing window", rules: "POST /order-service/orders user=10", wantErr: true},
+		{name: "zero requests", rules: "POST /order-service/orders user=0/1m", wantErr: true},
+		{name: "window below a second", rules: "POST /order-service/orders user=10/500ms", wantErr: true},
+		{name: "unknown scope", rules: "POST /order-service/orders shop=10/1m", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RateLimitConfig{Rules: tt.rules}.ParseRules()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRules() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
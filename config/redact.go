@@ -0,0 +1,84 @@
+package config
+
+import (
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+// RedactedValue replaces the value of fields tagged with `secret:"true"`
+// whenever the configuration is logged or printed.
+const RedactedValue = "******"
+
+// LogValue implements slog.LogValuer so that logging the configuration never
+// writes secrets to the log pipeline.
+func (c Config) LogValue() slog.Value {
+	return redactStruct(reflect.ValueOf(c))
+}
+
+// String keeps secrets out of fmt verbs such as %v and %+v.
+func (c Config) String() string {
+	return c.LogValue().String()
+}
+
+func (c DbConfig) LogValue() slog.Value {
+	return redactStruct(reflect.ValueOf(c))
+}
+
+func (c JwtConfig) LogValue() slog.Value {
+	return redactStruct(reflect.ValueOf(c))
+}
+
+func (c EventConfig) LogValue() slog.Value {
+	return redactStruct(reflect.ValueOf(c))
+}
+
+// IsSecret reports whether the configuration key belongs to a field tagged
+// with `secret:"true"`.
+func IsSecret(key string) bool {
+	_, ok := secretKeys(reflect.TypeOf(Config{}))[key]
+	return ok
+}
+
+func secretKeys(t reflect.Type) map[string]struct{} {
+	keys := map[string]struct{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if field.Type.Kind() == reflect.Struct && (opts == "squash" || name == "") {
+			for k := range secretKeys(field.Type) {
+				keys[k] = struct{}{}
+			}
+			continue
+		}
+		if field.Tag.Get("secret") == "true" {
+			keys[name] = struct{}{}
+		}
+	}
+	return keys
+}
+
+func redactStruct(v reflect.Value) slog.Value {
+	t := v.Type()
+	attrs := make([]slog.Attr, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		switch {
+		case field.Tag.Get("secret") == "true":
+			value := ""
+			if !fv.IsZero() {
+				value = RedactedValue
+			}
+			attrs = append(attrs, slog.String(field.Name, value))
+		case fv.Kind() == reflect.Struct:
+			attrs = append(attrs, slog.Attr{Key: field.Name, Value: redactStruct(fv)})
+		default:
+			attrs = append(attrs, slog.Any(field.Name, fv.Interface()))
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestConfigRedaction(t *testing.T) {
+	cfg := Config{
+		Port:               "8080",
+		InternalAuthHeader: "internal-secret",
+		Db:                 DbConfig{Host: "db.internal", Password: "db-secret"},
+		Jwt:                JwtConfig{Mode: "hmac", SecretKey: "jwt-secret"},
+	}
+	secrets := []string{"internal-secret", "db-secret", "jwt-secret"}
+
+	logged := func(args ...any) string {
+		var buf bytes.Buffer
+		slog.New(slog.NewJSONHandler(&buf, nil)).Info("config", args...)
+		return buf.String()
+	}
+
+	tests := []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{name: "%v", out: fmt.Sprintf("%v", cfg), want: []string{"db.internal", RedactedValue}},
+		{name: "%+v", out: fmt.Sprintf("%+v", cfg), want: []string{"db.internal", RedactedValue}},
+		{name: "logged config", out: logged("config", cfg), want: []string{`"Host":"db.internal"`, `"Password":"` + RedactedValue + `"`, `"AuthPaymentHeader":""`}},
+		{name: "logged section", out: logged("db", cfg.Db, "jwt", cfg.Jwt), want: []string{`"Host":"db.internal"`, `"SecretKey":"` + RedactedValue + `"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, secret := range secrets {
+				if strings.Contains(tt.out, secret) {
+					t.Errorf("output leaks %q: %s", secret, tt.out)
+				}
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(tt.out, want) {
+					t.Errorf("output is missing %q: %s", want, tt.out)
+				}
+			}
+		})
+	}
+}
+
+func TestIsSecret(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{key: "DB_PASSWORD", want: true},
+		{key: "JWT_SECRETKEY", want: true},
+		{key: "INTERNAL_AUTH_HEADER", want: true},
+		{key: "QUOTE_SECRET", want: true},
+		{key: "DB_HOST"},
+		{key: "JWT_EXPIRE"},
+		{key: "UNKNOWN_KEY"},
+	}
+	for _, tt := range tests {
+		if got := IsSecret(tt.key); got != tt.want {
+			t.Errorf("IsSecret(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
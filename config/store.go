@@ -0,0 +1,204 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce groups the burst of events editors and secret mounts
+// produce for a single change.
+const reloadDebounce = 500 * time.Millisecond
+
+// Store holds the current configuration and swaps it atomically on reload.
+// Components either read Get() per operation or Subscribe to the subset of
+// the configuration they cache.
+type Store struct {
+	current atomic.Pointer[Config]
+
+	// mu serializes reloads and subscriber registration
+	mu          sync.Mutex
+	subscribers []subscription
+}
+
+type subscription struct {
+	name     string
+	selector func(*Config) any
+	onChange func(*Config)
+}
+
+func NewStore(cfg *Config) *Store {
+	s := &Store{}
+	s.current.Store(cfg)
+	return s
+}
+
+// Get returns the current configuration snapshot, it must not be modified.
+func (s *Store) Get() *Config {
+	return s.current.Load()
+}
+
+// Subscribe registers onChange to be called after a reload whenever the value
+// returned by selector differs from the previous configuration.
+func (s *Store) Subscribe(name string, selector func(*Config) any, onChange func(*Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, subscription{name: name, selector: selector, onChange: onChange})
+}
+
+// Reload loads and validates the configuration again. An invalid
+// configuration is rejected and the current one is kept.
+func (s *Store) Reload(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next, _, err := load(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "[ConfigStore] Reload rejected", "error", err)
+		return err
+	}
+	keepRestartOnly(ctx, s.current.Load(), next)
+	if err := Validate(ctx, next); err != nil {
+		slog.ErrorContext(ctx, "[ConfigStore] Reload rejected", "error", err)
+		return err
+	}
+
+	prev := s.current.Swap(next)
+
+	for _, sub := range s.subscribers {
+		if reflect.DeepEqual(sub.selector(prev), sub.selector(next)) {
+			continue
+		}
+		slog.InfoContext(ctx, "[ConfigStore] Notifying subscriber", "subscriber", sub.name)
+		sub.onChange(next)
+	}
+
+	slog.InfoContext(ctx, "[ConfigStore] Config reloaded successfully")
+	return nil
+}
+
+// keepRestartOnly copies the settings that are only applied on startup from
+// prev to next, so Get never reports a value the running process does not
+// use. The JWT mode must stay with the key set that was built for it.
+func keepRestartOnly(ctx context.Context, prev, next *Config) {
+	if prev.Port != next.Port || prev.Db != next.Db || prev.Proxy != next.Proxy {
+		slog.WarnContext(ctx, "[ConfigStore] Server port, proxy and database settings are applied on restart only")
+		next.Port, next.Db, next.Proxy = prev.Port, prev.Db, prev.Proxy
+	}
+	if prev.Mode != next.Mode || prev.RateLimit.Store != next.RateLimit.Store || prev.Pricing.TaxRulesFile != next.Pricing.TaxRulesFile {
+		slog.WarnContext(ctx, "[ConfigStore] APP_MODE, RATE_LIMIT_STORE and TAX_RULES_FILE are applied on restart only")
+		next.Mode, next.RateLimit.Store, next.Pricing.TaxRulesFile = prev.Mode, prev.RateLimit.Store, prev.Pricing.TaxRulesFile
+	}
+	if prev.Jwt.Mode != next.Jwt.Mode || prev.Jwt.JWKSURL != next.Jwt.JWKSURL || prev.Jwt.JWKSRefreshSeconds != next.Jwt.JWKSRefreshSeconds {
+		slog.WarnContext(ctx, "[ConfigStore] JWT mode and JWKS settings are applied on restart only")
+		next.Jwt.Mode, next.Jwt.JWKSURL, next.Jwt.JWKSRefreshSeconds = prev.Jwt.Mode, prev.Jwt.JWKSURL, prev.Jwt.JWKSRefreshSeconds
+	}
+
+	event := next.Event
+	event.Publisher, event.FilePath, event.NATSURL, event.NATSStream = prev.Event.Publisher, prev.Event.FilePath, prev.Event.NATSURL, prev.Event.NATSStream
+	event.Subscriber, event.ConsumerName = prev.Event.Subscriber, prev.Event.ConsumerName
+	event.PaymentSucceededTopic, event.PaymentFailedTopic, event.StockReleasedTopic =
+		prev.Event.PaymentSucceededTopic, prev.Event.PaymentFailedTopic, prev.Event.StockReleasedTopic
+	if event != next.Event {
+		slog.WarnContext(ctx, "[ConfigStore] Event publisher and subscriber settings are applied on restart only")
+		next.Event = event
+	}
+
+	grpc := next.Grpc
+	grpc.Enabled, grpc.Port, grpc.AuthMode = prev.Grpc.Enabled, prev.Grpc.Port, prev.Grpc.AuthMode
+	grpc.TLSCertFile, grpc.TLSKeyFile, grpc.TLSClientCAFile = prev.Grpc.TLSCertFile, prev.Grpc.TLSKeyFile, prev.Grpc.TLSClientCAFile
+	if grpc != next.Grpc {
+		slog.WarnContext(ctx, "[ConfigStore] gRPC server settings are applied on restart only")
+		next.Grpc = grpc
+	}
+}
+
+// Watch reloads the configuration on SIGHUP and whenever the config file,
+// profile file, env file or a KEY_FILE secret changes. It blocks until ctx is done.
+func (s *Store) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	files, secretDirs := watchedPaths()
+	dirs := map[string]struct{}{}
+	for f := range files {
+		dirs[filepath.Dir(f)] = struct{}{}
+	}
+	for d := range secretDirs {
+		dirs[d] = struct{}{}
+	}
+	for d := range dirs {
+		if err := watcher.Add(d); err != nil {
+			slog.WarnContext(ctx, "[ConfigStore] Unable to watch directory", "dir", d, "error", err)
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			slog.InfoContext(ctx, "[ConfigStore] SIGHUP received, reloading config")
+			_ = s.Reload(ctx)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			name := filepath.Clean(event.Name)
+			_, isFile := files[name]
+			_, isSecret := secretDirs[filepath.Dir(name)]
+			if isFile || isSecret {
+				debounce = time.After(reloadDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			slog.InfoContext(ctx, "[ConfigStore] Config file changed, reloading config")
+			_ = s.Reload(ctx)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.WarnContext(ctx, "[ConfigStore] Watcher error", "error", err)
+		}
+	}
+}
+
+// watchedPaths returns the config files to watch, and the directories that
+// hold secret files. Secret mounts are usually updated through symlink swaps,
+// so any change in those directories triggers a reload.
+func watchedPaths() (map[string]struct{}, map[string]struct{}) {
+	files := map[string]struct{}{}
+	base, profile := configFiles()
+	for _, f := range []string{base, profile, envFile()} {
+		if f != "" {
+			files[filepath.Clean(f)] = struct{}{}
+		}
+	}
+
+	secretDirs := map[string]struct{}{}
+	for _, key := range Keys() {
+		if path := os.Getenv(key + "_FILE"); strings.TrimSpace(path) != "" {
+			secretDirs[filepath.Dir(filepath.Clean(path))] = struct{}{}
+		}
+	}
+	return files, secretDirs
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeepRestartOnly(t *testing.T) {
+	prev := &Config{
+		Port:      "8080",
+		Mode:      "server",
+		Jwt:       JwtConfig{Mode: "hmac", SecretKey: "old", JWKSRefreshSeconds: 300},
+		Event:     EventConfig{Publisher: "file", FilePath: "events.ndjson", TopicPrefix: "old", RelayBatchSize: 100},
+		RateLimit: RateLimitConfig{Enabled: true, Store: "memory"},
+		Pricing:   PricingConfig{ShippingFees: "standard:10000", TaxRulesFile: "tax_rules.json"},
+		Grpc:      GrpcConfig{Enabled: false, Port: "9090", AuthMode: "secret", WatchPollSeconds: 2},
+	}
+	next := &Config{
+		Port:      "9090",
+		Mode:      "all",
+		Jwt:       JwtConfig{Mode: "jwks", SecretKey: "new", JWKSURL: "https://idp/jwks.json", JWKSRefreshSeconds: 60},
+		Event:     EventConfig{Publisher: "nats", NATSURL: "nats://broker:4222", TopicPrefix: "new", RelayBatchSize: 50},
+		RateLimit: RateLimitConfig{Enabled: false, Store: "postgres"},
+		Pricing:   PricingConfig{ShippingFees: "standard:15000", TaxRulesFile: "tax_rules.v2.json"},
+		Grpc:      GrpcConfig{Enabled: true, Port: "9191", AuthMode: "mtls", TLSCertFile: "server.pem", WatchPollSeconds: 5},
+	}
+
+	keepRestartOnly(context.Background(), prev, next)
+
+	if next.Port != "8080" {
+		t.Errorf("Port = %q, want the startup value", next.Port)
+	}
+	if next.Jwt.Mode != "hmac" || next.Jwt.JWKSURL != "" || next.Jwt.JWKSRefreshSeconds != 300 {
+		t.Errorf("Jwt = %+v, want the startup mode and JWKS settings", next.Jwt)
+	}
+	if next.Jwt.SecretKey != "new" {
+		t.Errorf("SecretKey = %q, secrets must still reload", next.Jwt.SecretKey)
+	}
+	if next.Event.Publisher != "file" || next.Event.FilePath != "events.ndjson" || next.Event.NATSURL != "" {
+		t.Errorf("Event = %+v, want the startup publisher settings", next.Event)
+	}
+	if next.Event.TopicPrefix != "new" || next.Event.RelayBatchSize != 50 {
+		t.Errorf("Event = %+v, topic prefix and relay batch size must still reload", next.Event)
+	}
+	if next.Mode != "server" || next.RateLimit.Store != "memory" || next.Pricing.TaxRulesFile != "tax_rules.json" {
+		t.Errorf("Mode = %q, rate limit store = %q, tax rules = %q, want the startup values", next.Mode, next.RateLimit.Store, next.Pricing.TaxRulesFile)
+	}
+	if next.RateLimit.Enabled || next.Pricing.ShippingFees != "standard:15000" {
+		t.Errorf("RateLimit = %+v, Pricing = %+v, rate limit toggle and shipping fees must still reload", next.RateLimit, next.Pricing)
+	}
+	if want := (GrpcConfig{Port: "9090", AuthMode: "secret", WatchPollSeconds: 5}); next.Grpc != want {
+		t.Errorf("Grpc = %+v, want the startup server settings with the new poll interval %+v", next.Grpc, want)
+	}
+}
+
+// writeEnvFile points ENV_FILE at the sample env with the missing required
+// keys and extra appended, later lines overriding earlier ones.
+func writeEnvFile(t *testing.T, path, extra string) {
+	t.Helper()
+	sample, err := os.ReadFile("../.sample_env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(sample) + "\nPRODUCT_SERVICE_HOST=localhost:8084\n" + extra
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreReloadNotifiesSubscribers(t *testing.T) {
+	tests := []struct {
+		name          string
+		extra         string
+		wantErr       bool
+		wantRateLimit int
+		wantWebhook   int
+	}{
+		{name: "unchanged"},
+		{name: "changed section", extra: "RATE_LIMIT_RULES=POST /order-service/orders user=5/1m\n", wantRateLimit: 1},
+		{name: "several sections", extra: "RATE_LIMIT_ENABLED=false\nWEBHOOK_MAX_ATTEMPTS=3\n", wantRateLimit: 1, wantWebhook: 1},
+		{name: "restart-only setting", extra: "PORT=9999\n"},
+		{name: "restart-only setting of a section", extra: "RATE_LIMIT_STORE=postgres\n"},
+		{name: "quote tokens enabled", extra: "QUOTE_ENABLED=true\nQUOTE_SECRET=quote-secret-quote-secret-quote-secret\n"},
+		{name: "quote tokens enabled without a secret", extra: "QUOTE_ENABLED=true\n", wantErr: true},
+		{name: "invalid config is rejected", extra: "LOG_LEVEL=verbose\nWEBHOOK_MAX_ATTEMPTS=3\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+			envPath := filepath.Join(dir, ".env")
+			t.Setenv("CONFIG_FILE", filepath.Join(dir, "config.yaml"))
+			t.Setenv("APP_PROFILE", "")
+			t.Setenv("ENV_FILE", envPath)
+
+			writeEnvFile(t, envPath, "")
+			cfg, _, err := load(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Validate(ctx, cfg); err != nil {
+				t.Fatal(err)
+			}
+			store := NewStore(cfg)
+
+			calls := map[string]int{}
+			subscribe := func(name string, selector func(*Config) any) {
+				store.Subscribe(name, selector, func(*Config) { calls[name]++ })
+			}
+			subscribe("rate-limit", func(c *Config) any { return c.RateLimit })
+			subscribe("webhook", func(c *Config) any { return c.Webhook })
+			subscribe("port", func(c *Config) any { return c.Port })
+
+			writeEnvFile(t, envPath, tt.extra)
+			err = store.Reload(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reload() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && store.Get() != cfg {
+				t.Error("a rejected reload must keep the current configuration")
+			}
+			// the port is restart-only, so its subscriber is never notified
+			if calls["rate-limit"] != tt.wantRateLimit || calls["webhook"] != tt.wantWebhook || calls["port"] != 0 {
+				t.Errorf("calls = %v, want rate-limit %d, webhook %d, port 0", calls, tt.wantRateLimit, tt.wantWebhook)
+			}
+		})
+	}
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orders_shop_id_created_at;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS shop_id;
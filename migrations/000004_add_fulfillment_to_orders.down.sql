@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS order_histories;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS courier,
+    DROP COLUMN IF EXISTS tracking_number,
+    DROP COLUMN IF EXISTS shipped_at;
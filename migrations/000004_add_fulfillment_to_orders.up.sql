@@ -0,0 +1,17 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS courier VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS tracking_number VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS shipped_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS order_histories (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders (id),
+    from_status VARCHAR(32) NOT NULL,
+    to_status VARCHAR(32) NOT NULL,
+    actor_type VARCHAR(16) NOT NULL,
+    actor_id BIGINT NOT NULL DEFAULT 0,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_histories_order_id ON order_histories (order_id, created_at);
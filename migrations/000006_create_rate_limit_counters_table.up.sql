@@ -0,0 +1,9 @@
+-- fixed window counters shared by all replicas when RATE_LIMIT_STORE=postgres
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_counters (
+    key VARCHAR(255) NOT NULL,
+    reset_at TIMESTAMPTZ NOT NULL,
+    count BIGINT NOT NULL,
+    PRIMARY KEY (key, reset_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_counters_reset_at ON rate_limit_counters (reset_at);
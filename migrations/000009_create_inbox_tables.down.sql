@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS dead_letter_messages;
+DROP TABLE IF EXISTS processed_messages;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS late_payments (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders (id),
+    payment_id VARCHAR(100) NOT NULL DEFAULT '',
+    outcome VARCHAR(32) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_late_payments_order_id ON late_payments (order_id);
+CREATE INDEX IF NOT EXISTS idx_late_payments_outcome ON late_payments (outcome, id);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS refunds (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders (id),
+    quantity BIGINT NOT NULL CHECK (quantity > 0),
+    amount BIGINT NOT NULL DEFAULT 0,
+    reason VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL,
+    stock_action VARCHAR(16) NOT NULL DEFAULT '',
+    requested_by_type VARCHAR(16) NOT NULL,
+    requested_by_id BIGINT NOT NULL DEFAULT 0,
+    provider_reference VARCHAR(100) NOT NULL DEFAULT '',
+    failure_reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    confirmed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_refunds_order_id ON refunds (order_id, id);
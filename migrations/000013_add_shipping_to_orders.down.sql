@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS order_shipping_addresses;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS delivery_method;
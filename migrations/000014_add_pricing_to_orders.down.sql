@@ -0,0 +1,6 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS unit_price,
+    DROP COLUMN IF EXISTS subtotal,
+    DROP COLUMN IF EXISTS shipping_fee,
+    DROP COLUMN IF EXISTS discount,
+    DROP COLUMN IF EXISTS total;
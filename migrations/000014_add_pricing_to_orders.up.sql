@@ -0,0 +1,6 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS unit_price BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS subtotal BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS shipping_fee BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS discount BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS total BIGINT NOT NULL DEFAULT 0;
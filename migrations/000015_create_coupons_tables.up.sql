@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS coupons (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    type VARCHAR(16) NOT NULL,
+    value BIGINT NOT NULL DEFAULT 0,
+    max_discount BIGINT NOT NULL DEFAULT 0,
+    min_subtotal BIGINT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    usage_limit BIGINT NOT NULL DEFAULT 0,
+    per_user_limit BIGINT NOT NULL DEFAULT 0,
+    product_ids BIGINT[] NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id BIGSERIAL PRIMARY KEY,
+    coupon_id BIGINT NOT NULL REFERENCES coupons (id),
+    order_id BIGINT NOT NULL UNIQUE REFERENCES orders (id),
+    user_id BIGINT NOT NULL,
+    code VARCHAR(32) NOT NULL,
+    type VARCHAR(16) NOT NULL,
+    item_discount BIGINT NOT NULL DEFAULT 0,
+    shipping_discount BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(16) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    released_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_active ON coupon_redemptions (coupon_id, user_id) WHERE status = 'redeemed';
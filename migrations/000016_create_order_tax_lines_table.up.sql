@@ -0,0 +1,17 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS order_tax_lines (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+    component VARCHAR(16) NOT NULL,
+    name VARCHAR(50) NOT NULL,
+    region VARCHAR(8) NOT NULL,
+    category VARCHAR(50) NOT NULL,
+    rate_bps BIGINT NOT NULL,
+    inclusive BOOLEAN NOT NULL,
+    taxable_amount BIGINT NOT NULL,
+    amount BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_tax_lines_order_id ON order_tax_lines (order_id, id);
@@ -0,0 +1 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS expiry_extended_at TIMESTAMPTZ;
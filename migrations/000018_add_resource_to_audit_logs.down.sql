@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_audit_logs_resource;
+
+-- entries without an order cannot be kept once order_id is required again
+ALTER TABLE audit_logs DISABLE TRIGGER audit_logs_no_update_delete;
+DELETE FROM audit_logs WHERE order_id IS NULL;
+ALTER TABLE audit_logs ENABLE TRIGGER audit_logs_no_update_delete;
+
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS resource_id;
+ALTER TABLE audit_logs DROP COLUMN IF EXISTS resource_type;
+ALTER TABLE audit_logs ALTER COLUMN order_id SET NOT NULL;
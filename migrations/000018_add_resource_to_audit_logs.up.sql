@@ -0,0 +1,14 @@
+-- audit logs also record changes that are not about an order
+ALTER TABLE audit_logs ALTER COLUMN order_id DROP NOT NULL;
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS resource_type VARCHAR(32) NOT NULL DEFAULT 'order';
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS resource_id BIGINT;
+
+-- the append-only trigger is lifted for the backfill only
+ALTER TABLE audit_logs DISABLE TRIGGER audit_logs_no_update_delete;
+UPDATE audit_logs SET resource_id = order_id WHERE resource_id IS NULL;
+ALTER TABLE audit_logs ENABLE TRIGGER audit_logs_no_update_delete;
+
+ALTER TABLE audit_logs ALTER COLUMN resource_id SET NOT NULL;
+ALTER TABLE audit_logs ALTER COLUMN resource_type DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_resource ON audit_logs (resource_type, resource_id);
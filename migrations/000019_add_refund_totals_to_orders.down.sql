@@ -0,0 +1,6 @@
+UPDATE orders SET status = 'partially_refunded'
+WHERE refunded_quantity > 0 AND status <> 'refunded';
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS refunded_quantity,
+    DROP COLUMN IF EXISTS refunded_amount;
@@ -0,0 +1,21 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS refunded_quantity BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS refunded_amount BIGINT NOT NULL DEFAULT 0;
+
+UPDATE orders o SET refunded_quantity = r.quantity, refunded_amount = r.amount
+FROM (
+    SELECT order_id, SUM(quantity) AS quantity, SUM(amount) AS amount
+    FROM refunds WHERE status = 'succeeded' GROUP BY order_id
+) r
+WHERE r.order_id = o.id;
+
+-- a partial refund no longer changes the status, partially refunded orders
+-- get back the status they had before their first partial refund
+UPDATE orders o SET status = h.from_status
+FROM (
+    SELECT DISTINCT ON (order_id) order_id, from_status
+    FROM order_histories
+    WHERE to_status = 'partially_refunded' AND from_status <> 'partially_refunded'
+    ORDER BY order_id, id
+) h
+WHERE h.order_id = o.id AND o.status = 'partially_refunded';
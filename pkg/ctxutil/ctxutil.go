@@ -11,6 +11,11 @@ const (
 	RequestIDKey ctxKey = "request_id"
 	UserIDKey    ctxKey = "user_id"
 	ShopIDKey    ctxKey = "shop_id"
+	RolesKey     ctxKey = "roles"
+	PermsKey     ctxKey = "perms"
+	SourceIPKey  ctxKey = "source_ip"
+
+	ExpectedVersionKey ctxKey = "expected_version"
 )
 
 func WithRequestID(ctx context.Context, reqID string) context.Context {
@@ -26,6 +31,32 @@ func GetRequestID(ctx context.Context) string {
 	return ""
 }
 
+func WithSourceIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, SourceIPKey, ip)
+}
+
+func GetSourceIP(ctx context.Context) string {
+	if v := ctx.Value(SourceIPKey); v != nil {
+		if ip, ok := v.(string); ok {
+			return ip
+		}
+	}
+	return ""
+}
+
+func WithExpectedVersion(ctx context.Context, version int64) context.Context {
+	return context.WithValue(ctx, ExpectedVersionKey, version)
+}
+
+// GetExpectedVersion returns the order version sent in If-Match, ok is false
+// when the request did not ask for one.
+func GetExpectedVersion(ctx context.Context) (version int64, ok bool) {
+	if v := ctx.Value(ExpectedVersionKey); v != nil {
+		version, ok = v.(int64)
+	}
+	return version, ok
+}
+
 func GetUserIDCtx(ctx context.Context) (int64, error) {
 	if v := ctx.Value(UserIDKey); v != nil {
 		if id, ok := v.(int64); ok {
@@ -43,3 +74,21 @@ func GetShopIDCtx(ctx context.Context) (int64, error) {
 	}
 	return 0, errors.New("shop ID not found")
 }
+
+func GetRolesCtx(ctx context.Context) []string {
+	if v := ctx.Value(RolesKey); v != nil {
+		if roles, ok := v.([]string); ok {
+			return roles
+		}
+	}
+	return nil
+}
+
+func GetPermsCtx(ctx context.Context) []string {
+	if v := ctx.Value(PermsKey); v != nil {
+		if perms, ok := v.([]string); ok {
+			return perms
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package pkg
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// FormatETag returns the strong entity tag of an order version.
+func FormatETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ParseIfMatch reads the order version from an If-Match header value. Weak
+// tags are accepted since the version covers the whole order, wildcard is
+// true for "*".
+func ParseIfMatch(value string) (version int64, wildcard bool, err error) {
+	value = strings.TrimSpace(value)
+	if value == "*" {
+		return 0, true, nil
+	}
+	value = strings.TrimPrefix(value, "W/")
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return 0, false, errors.New("If-Match must be a single quoted entity tag")
+	}
+	version, err = strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false, errors.New("If-Match does not hold an order version")
+	}
+	return version, false, nil
+}
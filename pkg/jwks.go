@@ -0,0 +1,225 @@
+package pkg
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minForcedRefresh limits how often an unknown kid can trigger a fetch, so
+// tokens with random kids cannot be used to hammer the key endpoint.
+const minForcedRefresh = 10 * time.Second
+
+var ErrKeyNotFound = errors.New("signing key not found")
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// JWKS is a cached JSON Web Key Set loaded from a local file or an HTTP(S)
+// URL. Keys are looked up by kid and refreshed after the refresh interval.
+type JWKS struct {
+	source          string
+	refreshInterval time.Duration
+	httpClient      *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]any
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+func NewJWKS(source string, refreshInterval time.Duration) *JWKS {
+	return &JWKS{
+		source:          source,
+		refreshInterval: refreshInterval,
+		httpClient:      &http.Client{Timeout: 10 * time.Second},
+		keys:            map[string]any{},
+	}
+}
+
+// Key returns the public key for kid. A stale or missing key triggers a
+// refresh; if the refresh fails a previously cached key is still served.
+func (k *JWKS) Key(ctx context.Context, kid string) (any, error) {
+	key, ok, stale := k.lookup(kid)
+	if ok && !stale {
+		return key, nil
+	}
+
+	if err := k.refresh(ctx, !ok); err != nil {
+		if ok {
+			slog.WarnContext(ctx, "[JWKS] refresh failed, using cached key", "kid", kid, "error", err)
+			return key, nil
+		}
+		return nil, err
+	}
+
+	key, ok, _ = k.lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("%w: kid %q", ErrKeyNotFound, kid)
+	}
+	return key, nil
+}
+
+func (k *JWKS) lookup(kid string) (any, bool, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	stale := time.Since(k.fetchedAt) > k.refreshInterval
+	if kid == "" && len(k.keys) == 1 {
+		// tokens without kid are accepted only when the set is unambiguous
+		for _, key := range k.keys {
+			return key, true, stale
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok, stale
+}
+
+// Refresh fetches the key set unconditionally.
+func (k *JWKS) Refresh(ctx context.Context) error {
+	return k.refresh(ctx, false)
+}
+
+func (k *JWKS) refresh(ctx context.Context, forced bool) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if forced && time.Since(k.lastAttempt) < minForcedRefresh {
+		return fmt.Errorf("%w: refresh throttled", ErrKeyNotFound)
+	}
+	k.lastAttempt = time.Now()
+
+	raw, err := k.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("fetch jwks: %w", err)
+	}
+
+	keys, err := parseJWKS(raw)
+	if err != nil {
+		return fmt.Errorf("parse jwks: %w", err)
+	}
+
+	k.keys = keys
+	k.fetchedAt = time.Now()
+	slog.InfoContext(ctx, "[JWKS] key set refreshed", "source", k.source, "keys", len(keys))
+	return nil
+}
+
+func (k *JWKS) fetch(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(k.source, "http://") && !strings.HasPrefix(k.source, "https://") {
+		return os.ReadFile(strings.TrimPrefix(k.source, "file://"))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func parseJWKS(raw []byte) (map[string]any, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]any, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		pub, err := key.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key.Kid, err)
+		}
+		keys[key.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("exponent: %w", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("x: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("y: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
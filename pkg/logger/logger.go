@@ -1,13 +1,28 @@
 package logger
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 )
 
+var level slog.LevelVar
+
 func InitLogger() {
+	level.Set(slog.LevelInfo)
 	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		Level: &level,
 	})
-	slog.SetDefault(slog.New(&RequestIDHandler{Handler: handler}))
+	slog.SetDefault(slog.New(&RequestIDHandler{Handler: NewRedactHandler(handler, DefaultSensitiveKeys)}))
+}
+
+// SetLevel changes the level of the default logger at runtime.
+func SetLevel(name string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.ToUpper(name))); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", name, err)
+	}
+	level.Set(l)
+	return nil
 }
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+const redacted = "******"
+
+// DefaultSensitiveKeys are matched case-insensitively against attribute keys,
+// an attribute is masked when its key contains any of them.
+var DefaultSensitiveKeys = []string{
+	"password",
+	"secret",
+	"token",
+	"authorization",
+	"auth_header",
+	"x-payment-auth",
+	"x-internal-auth",
+	"api_key",
+	"cookie",
+}
+
+// RedactHandler masks the values of attributes whose key looks sensitive,
+// including attributes nested in groups and those added through With.
+type RedactHandler struct {
+	handler slog.Handler
+	keys    []string
+}
+
+func NewRedactHandler(handler slog.Handler, keys []string) *RedactHandler {
+	lowered := make([]string, len(keys))
+	for i, k := range keys {
+		lowered[i] = strings.ToLower(k)
+	}
+	return &RedactHandler{handler: handler, keys: lowered}
+}
+
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(h.redact(a))
+		return true
+	})
+	return h.handler.Handle(ctx, nr)
+}
+
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redactedAttrs := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redactedAttrs[i] = h.redact(a)
+	}
+	return &RedactHandler{handler: h.handler.WithAttrs(redactedAttrs), keys: h.keys}
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{handler: h.handler.WithGroup(name), keys: h.keys}
+}
+
+func (h *RedactHandler) redact(a slog.Attr) slog.Attr {
+	if h.isSensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	// resolve LogValuers first so their output is inspected as well
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup {
+		return a
+	}
+
+	group := a.Value.Group()
+	attrs := make([]slog.Attr, len(group))
+	for i, ga := range group {
+		attrs[i] = h.redact(ga)
+	}
+	return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}
+}
+
+func (h *RedactHandler) isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range h.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
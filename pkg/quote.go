@@ -0,0 +1,41 @@
+package pkg
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// QuoteClaims pin the order request a quote was made for and the prices it
+// holds. A quote token is only honoured for the same request.
+type QuoteClaims struct {
+	UserID         int64  `json:"uid"`
+	ShopID         int64  `json:"shop_id"`
+	ProductID      int64  `json:"product_id"`
+	Quantity       int64  `json:"quantity"`
+	DeliveryMethod string `json:"delivery_method"`
+	Country        string `json:"country,omitempty"`
+	CouponCode     string `json:"coupon_code,omitempty"`
+	UnitPrice      int64  `json:"unit_price"`
+	ShippingFee    int64  `json:"shipping_fee"`
+	jwt.RegisteredClaims
+}
+
+// SignQuoteToken returns claims as an HS256 token that expires at expiresAt.
+func SignQuoteToken(secret string, claims QuoteClaims, expiresAt time.Time) (string, error) {
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	claims.IssuedAt = jwt.NewNumericDate(time.Now())
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+// ParseQuoteToken verifies the signature and the expiry of a quote token.
+func ParseQuoteToken(secret, token string) (QuoteClaims, error) {
+	var claims QuoteClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (any, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return QuoteClaims{}, err
+	}
+	return claims, nil
+}
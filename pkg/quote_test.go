@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestParseQuoteToken(t *testing.T) {
+	secret := "quote-secret-quote-secret-quote-secret"
+	claims := QuoteClaims{UserID: 7, ShopID: 3, ProductID: 11, Quantity: 2, DeliveryMethod: "standard", UnitPrice: 50000, ShippingFee: 10000}
+
+	sign := func(expiresAt time.Time) string {
+		token, err := SignQuoteToken(secret, claims, expiresAt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := sign(time.Now().Add(time.Minute))
+	noExpiry, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a lowered unit price under the original signature
+	cheaper := claims
+	cheaper.UnitPrice = 1
+	payload, err := json.Marshal(cheaper)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, ".")
+	tampered := strings.Join([]string{parts[0], base64.RawURLEncoding.EncodeToString(payload), parts[2]}, ".")
+
+	tests := []struct {
+		name    string
+		secret  string
+		token   string
+		wantErr error
+	}{
+		{name: "valid", secret: secret, token: valid},
+		{name: "expired", secret: secret, token: sign(time.Now().Add(-time.Minute)), wantErr: jwt.ErrTokenExpired},
+		{name: "wrong secret", secret: "another-secret-another-secret-another", token: valid, wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "tampered claims", secret: secret, token: tampered, wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "no expiry", secret: secret, token: noExpiry, wantErr: jwt.ErrTokenRequiredClaimMissing},
+		{name: "alg none", secret: secret, token: unsigned, wantErr: jwt.ErrTokenSignatureInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQuoteToken(tt.secret, tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.UserID != claims.UserID || got.ProductID != claims.ProductID || got.UnitPrice != claims.UnitPrice || got.ShippingFee != claims.ShippingFee {
+				t.Errorf("claims = %+v, want %+v", got, claims)
+			}
+		})
+	}
+}
@@ -1,26 +1,78 @@
 package pkg
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
 type TokenClaims struct {
-	UID int64  `json:"uid"`
-	SID *int64 `json:"sid"`
+	UID         int64    `json:"uid"`
+	SID         *int64   `json:"sid"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"perms"`
+}
+
+// ErrNoVerificationKey is returned when VerifyOptions allows no signing
+// method, tokens are then refused instead of checked against an empty key.
+var ErrNoVerificationKey = errors.New("no token verification key configured")
+
+// VerifyOptions configures ParseJwtTokenWithOptions. HMAC tokens are accepted
+// only when HMACSecret is set, asymmetric tokens only when KeySet is set.
+type VerifyOptions struct {
+	HMACSecret string
+	KeySet     *JWKS
+	Issuer     string
+	Audience   string
+	Leeway     time.Duration
 }
 
 func ParseJwtToken(tokenString string, secretKey string) (TokenClaims, error) {
+	return ParseJwtTokenWithOptions(context.Background(), tokenString, VerifyOptions{HMACSecret: secretKey})
+}
+
+func ParseJwtTokenWithOptions(ctx context.Context, tokenString string, opts VerifyOptions) (TokenClaims, error) {
+	var methods []string
+	if opts.HMACSecret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg(), jwt.SigningMethodHS384.Alg(), jwt.SigningMethodHS512.Alg())
+	}
+	if opts.KeySet != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+	if len(methods) == 0 {
+		return TokenClaims{}, ErrNoVerificationKey
+	}
+
+	parserOpts := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithLeeway(opts.Leeway)}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
 
 	// Parse the token
 	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
 		// Check the signing method
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if opts.HMACSecret == "" {
+				return nil, ErrNoVerificationKey
+			}
+			return []byte(opts.HMACSecret), nil
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+			if opts.KeySet == nil {
+				return nil, ErrNoVerificationKey
+			}
+			kid, _ := t.Header["kid"].(string)
+			return opts.KeySet.Key(ctx, kid)
+		default:
 			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 		}
-		return []byte(secretKey), nil
-	})
+	}, parserOpts...)
 	if err != nil {
 		return TokenClaims{}, err
 	}
@@ -35,12 +87,28 @@ func ParseJwtToken(tokenString string, secretKey string) (TokenClaims, error) {
 			tokenClaims.SID = new(int64)
 			*tokenClaims.SID = int64(shopID)
 		}
+		tokenClaims.Roles = stringSliceClaim(claims["roles"])
+		tokenClaims.Permissions = stringSliceClaim(claims["perms"])
 		return tokenClaims, nil
 	}
 
 	return TokenClaims{}, fmt.Errorf("invalid token claims")
 }
 
+func stringSliceClaim(v any) []string {
+	items, ok := v.([]any)
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
 func GetTokenFromHeaders(header string) (string, error) {
 	if header == "" {
 		return "", fmt.Errorf("missing token")
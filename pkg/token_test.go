@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writeJWKS(t *testing.T, kid string, key *rsa.PublicKey) *JWKS {
+	t.Helper()
+	set := map[string]any{"keys": []map[string]string{{
+		"kid": kid,
+		"kty": "RSA",
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	raw, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keySet := NewJWKS(path, time.Hour)
+	if err := keySet.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return keySet
+}
+
+func TestParseJwtTokenWithOptions(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keySet := writeJWKS(t, "k1", &rsaKey.PublicKey)
+
+	claims := jwt.MapClaims{"uid": float64(7), "exp": time.Now().Add(time.Hour).Unix()}
+	sign := func(method jwt.SigningMethod, key any) string {
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = "k1"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	hs256 := sign(jwt.SigningMethodHS256, []byte("secret"))
+	rs256 := sign(jwt.SigningMethodRS256, rsaKey)
+	unsigned := sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType)
+	// the RSA public key used as an HMAC secret, the classic algorithm confusion
+	confused := sign(jwt.SigningMethodHS256, rsaKey.PublicKey.N.Bytes())
+
+	tests := []struct {
+		name    string
+		token   string
+		opts    VerifyOptions
+		wantErr error
+	}{
+		{name: "hmac", token: hs256, opts: VerifyOptions{HMACSecret: "secret"}},
+		{name: "hmac wrong secret", token: hs256, opts: VerifyOptions{HMACSecret: "other"}, wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "jwks", token: rs256, opts: VerifyOptions{KeySet: keySet}},
+		{name: "hybrid accepts rs256", token: rs256, opts: VerifyOptions{HMACSecret: "secret", KeySet: keySet}},
+		{name: "hybrid accepts hs256", token: hs256, opts: VerifyOptions{HMACSecret: "secret", KeySet: keySet}},
+		{name: "rs256 in hmac mode", token: rs256, opts: VerifyOptions{HMACSecret: "secret"}, wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "hs256 in jwks mode", token: hs256, opts: VerifyOptions{KeySet: keySet}, wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "hs256 signed with public key", token: confused, opts: VerifyOptions{KeySet: keySet}, wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "alg none", token: unsigned, opts: VerifyOptions{HMACSecret: "secret", KeySet: keySet}, wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "empty secret without key set", token: hs256, opts: VerifyOptions{}, wantErr: ErrNoVerificationKey},
+		{name: "rs256 without key set", token: rs256, opts: VerifyOptions{}, wantErr: ErrNoVerificationKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseJwtTokenWithOptions(context.Background(), tt.token, tt.opts)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.UID != 7 {
+				t.Errorf("UID = %d, want 7", got.UID)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"order-service/app/domain"
+	"order-service/config"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	TagID                 = "id"
+	TagOrderQuantity      = "order_qty"
+	TagProductMaxQuantity = "product_max_qty"
+	TagNotes              = "notes"
+	TagPhone              = "phone"
+	TagPostalCode         = "postal_code"
+	TagAddressText        = "address_text"
+	TagCouponCode         = "coupon_code"
+)
+
+var (
+	phonePattern      = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+	postalCodePattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9 -]{1,8}[A-Za-z0-9])$`)
+	couponCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,32}$`)
+)
+
+// limits holds the reloadable order request limits, swapped atomically when
+// the configuration changes.
+type limits struct {
+	cfg                  config.OrderRequestConfig
+	productMaxQuantities map[int64]int64
+}
+
+func newLimits(cfg config.OrderRequestConfig) (*limits, error) {
+	productMaxQuantities, err := cfg.ProductMaxQuantities()
+	if err != nil {
+		return nil, err
+	}
+	return &limits{cfg: cfg, productMaxQuantities: productMaxQuantities}, nil
+}
+
+// New returns the request validator shared by all handlers, with the
+// order specific rules registered on it.
+func New(cfgStore *config.Store) (*validator.Validate, error) {
+	initial, err := newLimits(cfgStore.Get().OrderRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	var current atomic.Pointer[limits]
+	current.Store(initial)
+
+	cfgStore.Subscribe("request_validator", func(c *config.Config) any {
+		return c.OrderRequest
+	}, func(c *config.Config) {
+		next, err := newLimits(c.OrderRequest)
+		if err != nil {
+			slog.Error("[validation] reload order request limits", "error", err)
+			return
+		}
+		current.Store(next)
+	})
+
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// report json field names instead of Go field names
+	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		name, _, _ := strings.Cut(fld.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return fld.Name
+		}
+		return name
+	})
+
+	if err := v.RegisterValidation(TagID, validatePositiveID); err != nil {
+		return nil, err
+	}
+	if err := v.RegisterValidation(TagOrderQuantity, func(fl validator.FieldLevel) bool {
+		qty := fl.Field().Int()
+		return qty > 0 && qty <= current.Load().cfg.MaxQuantity
+	}); err != nil {
+		return nil, err
+	}
+	if err := v.RegisterValidation(TagNotes, func(fl validator.FieldLevel) bool {
+		return validateNotes(fl.Field().String(), current.Load().cfg.NotesMaxLength)
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := v.RegisterValidation(TagPhone, func(fl validator.FieldLevel) bool {
+		return phonePattern.MatchString(fl.Field().String())
+	}); err != nil {
+		return nil, err
+	}
+	if err := v.RegisterValidation(TagPostalCode, func(fl validator.FieldLevel) bool {
+		return postalCodePattern.MatchString(fl.Field().String())
+	}); err != nil {
+		return nil, err
+	}
+	if err := v.RegisterValidation(TagAddressText, validateAddressText); err != nil {
+		return nil, err
+	}
+	if err := v.RegisterValidation(TagCouponCode, func(fl validator.FieldLevel) bool {
+		return couponCodePattern.MatchString(fl.Field().String())
+	}); err != nil {
+		return nil, err
+	}
+
+	v.RegisterStructValidation(func(sl validator.StructLevel) {
+		req := sl.Current().Interface().(domain.OrderCreateRequest)
+		if limit, ok := current.Load().productMaxQuantities[req.ProductID]; ok && req.Quantity > limit {
+			sl.ReportError(req.Quantity, "quantity", "Quantity", TagProductMaxQuantity, strconv.FormatInt(limit, 10))
+		}
+	}, domain.OrderCreateRequest{})
+
+	v.RegisterStructValidation(func(sl validator.StructLevel) {
+		req := sl.Current().Interface().(domain.CouponRequest)
+		if req.Type == string(domain.CouponTypePercentage) && req.Value > 100 {
+			sl.ReportError(req.Value, "value", "Value", "lte", "100")
+		}
+		if req.StartsAt != nil && req.EndsAt != nil && !req.EndsAt.After(*req.StartsAt) {
+			sl.ReportError(req.EndsAt, "ends_at", "EndsAt", "gtfield", "starts_at")
+		}
+	}, domain.CouponRequest{})
+
+	return v, nil
+}
+
+func validatePositiveID(fl validator.FieldLevel) bool {
+	switch fl.Field().Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fl.Field().Int() > 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fl.Field().Uint() > 0
+	default:
+		return false
+	}
+}
+
+func validateNotes(notes string, maxLength int) bool {
+	if utf8.RuneCountInString(notes) > maxLength {
+		return false
+	}
+	for _, r := range notes {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// validateAddressText rejects control characters, address fields end up on
+// shipping labels so line breaks and tabs are not allowed either.
+func validateAddressText(fl validator.FieldLevel) bool {
+	for _, r := range fl.Field().String() {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Translate converts validator errors into a domain.ErrValidation with a
+// message that can be shown to API clients.
+func Translate(err error) error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return fmt.Errorf("%w: %v", domain.ErrValidation, err)
+	}
+
+	messages := make([]string, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		messages = append(messages, message(fe))
+	}
+	return fmt.Errorf("%w: %s", domain.ErrValidation, strings.Join(messages, "; "))
+}
+
+func message(fe validator.FieldError) string {
+	field := fe.Field()
+	if _, ns, ok := strings.Cut(fe.Namespace(), "."); ok {
+		field = ns
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case TagID:
+		return fmt.Sprintf("%s must be a positive ID", field)
+	case TagOrderQuantity:
+		return fmt.Sprintf("%s must be between 1 and the maximum allowed quantity", field)
+	case "eq=0|" + TagOrderQuantity:
+		return fmt.Sprintf("%s must be 0 to remove the line or between 1 and the maximum allowed quantity", field)
+	case TagProductMaxQuantity:
+		return fmt.Sprintf("%s must not exceed %s for this product", field, fe.Param())
+	case TagNotes:
+		return fmt.Sprintf("%s is too long or contains invalid characters", field)
+	case TagPhone:
+		return fmt.Sprintf("%s must be 7 to 15 digits with an optional leading +", field)
+	case TagPostalCode:
+		return fmt.Sprintf("%s is not a valid postal code", field)
+	case TagAddressText:
+		return fmt.Sprintf("%s contains invalid characters", field)
+	case TagCouponCode:
+		return fmt.Sprintf("%s must be 3 to 32 letters, digits, dashes or underscores", field)
+	case "gtfield":
+		return fmt.Sprintf("%s must be after %s", field, fe.Param())
+	case "required_unless":
+		return fmt.Sprintf("%s is required", field)
+	case "excluded_if":
+		return fmt.Sprintf("%s must not be set when %s", field, strings.Replace(fe.Param(), " ", " is ", 1))
+	case "iso3166_1_alpha2":
+		return fmt.Sprintf("%s must be an ISO 3166-1 alpha-2 country code", field)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, fe.Param())
+	case "gte":
+		return fmt.Sprintf("%s must be at least %s", field, fe.Param())
+	case "lte":
+		return fmt.Sprintf("%s must be at most %s", field, fe.Param())
+	case "min":
+		return fmt.Sprintf("%s must be at least %s characters", field, fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s characters", field, fe.Param())
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", field, fe.Param())
+	default:
+		return fmt.Sprintf("%s failed on the '%s' rule", field, fe.Tag())
+	}
+}
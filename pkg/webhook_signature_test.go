@@ -0,0 +1,34 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"order_id":1}`)
+	ts := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		ts     time.Time
+		body   []byte
+		want   string
+	}{
+		{name: "known signature", secret: "whsec", ts: ts, body: body, want: "sha256=af224a464063fc040275cb98303fb1d52596e70d3d7b97e47dc7a1ccffe78f9a"},
+		{name: "timestamp is signed", secret: "whsec", ts: ts.Add(time.Second), body: body, want: "sha256=b3e1127396c31c0cac5f5471003a1bbf29a7749ad11de91963774f94ab324a99"},
+		{name: "sub-second precision is dropped", secret: "whsec", ts: ts.Add(500 * time.Millisecond), body: body, want: "sha256=af224a464063fc040275cb98303fb1d52596e70d3d7b97e47dc7a1ccffe78f9a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignWebhookPayload(tt.secret, tt.ts, tt.body); got != tt.want {
+				t.Errorf("SignWebhookPayload() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if SignWebhookPayload("other", ts, body) == SignWebhookPayload("whsec", ts, body) {
+		t.Error("signatures with different secrets must differ")
+	}
+}